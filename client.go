@@ -3,14 +3,40 @@
 package apcupsd
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"io"
+	"math"
 	"net"
+	"sync"
 )
 
 // Client is a client for the apcupsd Network Information Server (NIS).
+//
+// A Client is safe for concurrent use. Requests are sent one at a time, so
+// concurrent requests wait for any request in progress to complete.
 type Client struct {
-	rwc io.ReadWriteCloser
+	// ParseMode specifies how Status handles malformed and unknown key/value
+	// pairs. The zero value is ParseFailFast.
+	ParseMode ParseMode
+
+	// MaxRecordSize specifies the maximum size in bytes of a single record
+	// received from the NIS. A larger record causes the request to fail with
+	// a ProtocolError wrapping ErrRecordTooLarge. If zero, apcupsd's own limit
+	// of 256 bytes is used.
+	MaxRecordSize int
+
+	// conn is the underlying connection, used to interrupt requests when a
+	// context is canceled.
+	conn io.Closer
+	nc   *Conn
+
+	// mu serializes requests. rb and sd are reused between requests to avoid
+	// allocations.
+	mu sync.Mutex
+	rb []byte
+	sd statusDecoder
 }
 
 // Dial dials a connection to an NIS using the address on the named network, and
@@ -26,7 +52,8 @@ func Dial(network, addr string) (*Client, error) {
 //
 // The provided Context must be non-nil. If the context expires before the
 // connection is complete, an error is returned. Once successfully connected,
-// any expiration of the context will not affect the connection.
+// any expiration of the context will not affect the connection. Use
+// StatusContext or EventsContext to bound individual requests.
 //
 // Typically, network will be one of: "tcp", "tcp4", or "tcp6".
 func DialContext(ctx context.Context, network, addr string) (*Client, error) {
@@ -43,11 +70,14 @@ func DialContext(ctx context.Context, network, addr string) (*Client, error) {
 // with an NIS. Client's Close method will close the io.ReadWriteCloser when
 // called.
 func New(rwc io.ReadWriteCloser) *Client {
-	return &Client{rwc: newNISReadWriteCloser(rwc)}
+	return &Client{
+		conn: rwc,
+		nc:   NewConn(rwc),
+	}
 }
 
 // Close closes the connection to an NIS.
-func (c *Client) Close() error { return c.rwc.Close() }
+func (c *Client) Close() error { return c.nc.Close() }
 
 const (
 	// maxString is the maximum string length for a NIS key/value pair. Value
@@ -56,32 +86,211 @@ const (
 )
 
 // Status retrieves the current UPS status from the NIS.
+//
+// If the Client's ParseMode is ParseLenient or ParseStrict and any key/value
+// pairs could not be parsed, the partially populated Status is returned along
+// with an error of type ParseErrors.
+//
+// If the status is missing its END APC record or some of the records declared
+// by its APC header, an error of type *IncompleteStatusError which wraps
+// io.ErrUnexpectedEOF is returned. In ParseLenient and ParseStrict modes, the
+// partially populated Status is returned along with it. If the status is
+// complete but its byte or record counts do not match its header, the Status
+// is returned along with an *IncompleteStatusError in every mode.
+//
+// If there are both parse errors and an *IncompleteStatusError, the returned
+// error wraps both, and errors.As must be used to retrieve the ParseErrors.
 func (c *Client) Status() (*Status, error) {
-	_, err := c.rwc.Write([]byte("status"))
+	return c.StatusContext(context.Background())
+}
+
+// StatusContext is like Status, but takes a context which bounds the lifetime
+// of the request.
+//
+// The provided Context must be non-nil. If the context is canceled or expires
+// before the response is complete, the Client's connection is closed to
+// interrupt the request and an error wrapping the context's error is
+// returned. The Client can no longer be used and should be closed.
+func (c *Client) StatusContext(ctx context.Context) (*Status, error) {
+	return c.status(ctx, new(Status))
+}
+
+// StatusInto is like StatusContext, but decodes the status into s, replacing
+// its contents, so that a caller which polls frequently can reuse a single
+// Status. The backing array of s.Raw is also reused, so any copy of s.Raw made
+// before the call may be overwritten.
+//
+// The contents of s after an error are as described for the Status returned
+// by Status. When no Status would be returned, they are unspecified.
+func (c *Client) StatusInto(ctx context.Context, s *Status) error {
+	_, err := c.status(ctx, s)
+	return err
+}
+
+// status retrieves the current UPS status from the NIS and decodes it into s.
+func (c *Client) status(ctx context.Context, s *Status) (*Status, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Every record is received before parsing begins, so the response is
+	// fully consumed even if parsing fails.
+	c.sd.reset()
+	err := c.command(ctx, "status", func(record []byte) error {
+		c.sd.add(record)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	b := make([]byte, maxString)
-	s := new(Status)
+	return c.sd.decode(s, c.ParseMode)
+}
 
-	// NIS server sends text lines containing key/value pairs, so must keep
-	// iterating until EOF to parse them all.
-	for {
-		n, err := c.rwc.Read(b)
-		if err == io.EOF {
-			// Received key/value pair with length 0.
-			break
-		}
-		if err != nil {
-			return nil, err
+// Events retrieves the event log from the NIS, oldest event first.
+func (c *Client) Events() ([]Event, error) {
+	return c.EventsContext(context.Background())
+}
+
+// EventsContext is like Events, but takes a context which bounds the lifetime
+// of the request. See StatusContext for details on context handling.
+func (c *Client) EventsContext(ctx context.Context) ([]Event, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []Event
+	err := c.command(ctx, "events", func(record []byte) error {
+		// Skip any blank lines in the log.
+		if len(bytes.TrimSpace(record)) == 0 {
+			return nil
 		}
 
-		// Parse key/value pair into appropriate struct field.
-		if err := s.parseKV(string(b[:n])); err != nil {
-			return nil, err
+		events = append(events, parseEvent(string(record)))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Do sends an arbitrary command to the NIS, such as a command added by a
+// patched apcupsd, and returns each record of the response. Most responses
+// are text lines which end with a newline.
+//
+// The provided Context must be non-nil. See StatusContext for details on
+// context handling.
+func (c *Client) Do(ctx context.Context, command string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var recs []string
+	err := c.command(ctx, command, func(record []byte) error {
+		recs = append(recs, string(record))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recs, nil
+}
+
+// command sends cmd to the NIS and invokes fn for each record in the
+// response, closing the connection if ctx is canceled before the response is
+// complete. The record passed to fn is only valid until fn returns. The caller
+// must hold c.mu.
+func (c *Client) command(ctx context.Context, cmd string, fn func(record []byte) error) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("apcupsd: %q command not sent: %w", cmd, err)
+	}
+
+	// No need to watch for cancelation if the context can never be canceled.
+	if ctx.Done() == nil {
+		return c.do(cmd, fn)
+	}
+
+	var (
+		stop = make(chan struct{})
+
+		// mu ensures the connection is never closed once the request is
+		// done, even if ctx is canceled at the same time.
+		mu           sync.Mutex
+		done, closed bool
+	)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			mu.Lock()
+			defer mu.Unlock()
+
+			if !done {
+				// Interrupt any blocked reads or writes. The framing of the
+				// connection can no longer be trusted, so it is not reused.
+				_ = c.conn.Close()
+				closed = true
+			}
+		case <-stop:
 		}
+	}()
+
+	err := c.do(cmd, fn)
+	close(stop)
+
+	mu.Lock()
+	defer mu.Unlock()
+	done = true
+
+	if closed {
+		// Even if the response was complete, the connection is now closed.
+		return fmt.Errorf("apcupsd: %q command interrupted: %w", cmd, ctx.Err())
+	}
+
+	return err
+}
+
+// maxRecordSize returns the maximum size of a record received from the NIS.
+func (c *Client) maxRecordSize() int {
+	switch {
+	case c.MaxRecordSize <= 0:
+		return maxString
+	case c.MaxRecordSize > math.MaxUint16:
+		// No record can be larger than its 2 byte length prefix allows.
+		return math.MaxUint16
+	default:
+		return c.MaxRecordSize
+	}
+}
+
+// do sends cmd to the NIS and invokes fn for each record in the response.
+func (c *Client) do(cmd string, fn func(record []byte) error) error {
+	if err := c.nc.WriteRecord([]byte(cmd)); err != nil {
+		return err
+	}
+
+	if n := c.maxRecordSize(); len(c.rb) != n {
+		c.rb = make([]byte, n)
 	}
+	b := c.rb
 
-	return s, nil
+	// NIS server sends text lines, so must keep iterating until EOF to
+	// process them all.
+	for recs := 0; ; recs++ {
+		n, err := c.nc.ReadRecord(b)
+		switch err {
+		case nil:
+		case io.EOF:
+			// Received record with length 0.
+			return nil
+		case io.ErrUnexpectedEOF:
+			return &TruncatedResponseError{Command: cmd, Records: recs}
+		default:
+			return err
+		}
+
+		if err := fn(b[:n]); err != nil {
+			return err
+		}
+	}
 }
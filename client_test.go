@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -28,7 +29,11 @@ func TestClientNoKnownKeyValuePairs(t *testing.T) {
 		t.Fatalf("failed to retrieve status: %v", err)
 	}
 
-	if diff := cmp.Diff(&Status{}, s); diff != "" {
+	want := &Status{
+		Raw: []KeyValue{{Key: "FOO", Value: "BAR"}},
+	}
+
+	if diff := cmp.Diff(want, s); diff != "" {
 		t.Fatalf("unexpected Status (-want +got):\n%s", diff)
 	}
 }
@@ -53,8 +58,18 @@ func TestClientAllTypesKeyValuePairs(t *testing.T) {
 		TimeLeft:        46*time.Minute + 30*time.Second,
 		TimeOnBattery:   0 * time.Second,
 		NumberTransfers: 0,
-		Selftest:        false,
+		Selftest:        SelftestNone,
 		NominalPower:    865,
+		Raw: []KeyValue{
+			{Key: "DATE", Value: "2016-09-06 22:13:28 -0400"},
+			{Key: "HOSTNAME", Value: "example"},
+			{Key: "LOADPCT", Value: "13.0 Percent Load Capacity"},
+			{Key: "TIMELEFT", Value: "46.5 Minutes"},
+			{Key: "TONBATT", Value: "0 seconds"},
+			{Key: "NUMXFERS", Value: "0"},
+			{Key: "SELFTEST", Value: "NO"},
+			{Key: "NOMPOWER", Value: "865 Watts"},
+		},
 	}
 
 	c := testClient(t, func() [][]byte {
@@ -78,6 +93,185 @@ func TestClientAllTypesKeyValuePairs(t *testing.T) {
 	}
 }
 
+func TestClientParseLenient(t *testing.T) {
+	c := testClient(t, func() [][]byte {
+		var out [][]byte
+		for _, kv := range []string{"HOSTNAME : example", "NUMXFERS : many"} {
+			lenb, kvb := kvBytes(kv)
+			out = append(out, lenb)
+			out = append(out, kvb)
+		}
+
+		return out
+	})
+	c.ParseMode = ParseLenient
+
+	s, err := c.Status()
+
+	var errs ParseErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Key != "NUMXFERS" {
+		t.Fatalf("expected NUMXFERS parse error, but got: %v", err)
+	}
+
+	if s == nil || s.Hostname != "example" {
+		t.Fatalf("expected partial status, but got: %#v", s)
+	}
+}
+
+func TestClientMaxRecordSize(t *testing.T) {
+	kv := "MODEL    : " + strings.Repeat("x", 300)
+
+	tests := []struct {
+		desc string
+		max  int
+		ok   bool
+	}{
+		{
+			desc: "default",
+		},
+		{
+			desc: "too small",
+			max:  32,
+		},
+		{
+			desc: "large enough",
+			max:  512,
+			ok:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			c := testClient(t, func() [][]byte {
+				lenb, kvb := kvBytes(kv)
+				return [][]byte{lenb, kvb}
+			})
+			c.MaxRecordSize = tt.max
+
+			s, err := c.Status()
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("failed to retrieve status: %v", err)
+				}
+
+				if diff := cmp.Diff(strings.Repeat("x", 300), s.Model); diff != "" {
+					t.Fatalf("unexpected model (-want +got):\n%s", diff)
+				}
+
+				return
+			}
+
+			var perr *ProtocolError
+			if !errors.As(err, &perr) || !errors.Is(err, ErrRecordTooLarge) || perr.Length != len(kv) {
+				t.Fatalf("expected record too large, but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestClientDo(t *testing.T) {
+	recs := []string{"first line\n", "second line\n"}
+
+	c := testClientCommand(t, "foo", func() [][]byte {
+		var out [][]byte
+		for _, r := range recs {
+			lenb, rb := kvBytes(r)
+			out = append(out, lenb, rb)
+		}
+
+		return out
+	})
+
+	got, err := c.Do(context.Background(), "foo")
+	if err != nil {
+		t.Fatalf("failed to send command: %v", err)
+	}
+
+	if diff := cmp.Diff(recs, got); diff != "" {
+		t.Fatalf("unexpected records (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientStatusInto(t *testing.T) {
+	// The malformed status must be fully consumed so that the following
+	// statuses can be retrieved on the same connection.
+	const bad = "NUMXFERS : many\nHOSTNAME : example\n"
+	dumps := []string{bad, dumpUSB, dumpSNMP, dumpUSB}
+
+	c := New(newReplayRWC(dumps...))
+
+	var s Status
+	for i, dump := range dumps {
+		err := c.StatusInto(context.Background(), &s)
+		if dump == bad {
+			if !errors.Is(err, strconv.ErrSyntax) {
+				t.Fatalf("expected syntax error, but got: %v", err)
+			}
+
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed to retrieve status %d: %v", i, err)
+		}
+
+		want, err := ParseStatus(strings.NewReader(dump))
+		if err != nil {
+			t.Fatalf("failed to parse status %d: %v", i, err)
+		}
+
+		if diff := cmp.Diff(want, &s); diff != "" {
+			t.Fatalf("unexpected Status %d (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestClientConcurrent(t *testing.T) {
+	addr := testServer(t, &testProvider{s: &Status{Hostname: "example"}})
+
+	c, err := Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("failed to dial Client: %v", err)
+	}
+	defer c.Close()
+
+	// Concurrent requests share a single connection and must not interleave.
+	var wg sync.WaitGroup
+	errC := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 10; j++ {
+				if i%2 == 0 {
+					if _, err := c.Events(); err != nil {
+						errC <- err
+						return
+					}
+
+					continue
+				}
+
+				s, err := c.Status()
+				if err != nil {
+					errC <- err
+					return
+				}
+				if s.Hostname != "example" {
+					errC <- fmt.Errorf("unexpected hostname: %q", s.Hostname)
+					return
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errC)
+	for err := range errC {
+		t.Fatalf("failed concurrent request: %v", err)
+	}
+}
+
 func TestClientTimeout(t *testing.T) {
 	l, err := net.Listen("tcp", ":0")
 	if err != nil {
@@ -97,7 +291,182 @@ func TestClientTimeout(t *testing.T) {
 	}
 }
 
+func TestClientEvents(t *testing.T) {
+	lines := []string{
+		"2016-09-05 21:44:09 -0400  apcupsd 3.14.14 (31 May 2016) debian startup succeeded\n",
+		"2016-09-06 22:10:00 -0400  Power failure.\n",
+		"2016-09-06 22:10:06 -0400  Running on UPS batteries.\n",
+		"2016-09-06 22:10:30 -0400  Mains returned. No longer on UPS batteries.\n",
+		"\n",
+		"apcupsd exiting, signal 15\n",
+	}
+
+	edt := time.FixedZone("EDT", -60*60*4)
+	want := []Event{
+		{
+			Time:    time.Date(2016, time.September, 5, 21, 44, 9, 0, edt),
+			Message: "apcupsd 3.14.14 (31 May 2016) debian startup succeeded",
+			Kind:    EventStartup,
+		},
+		{
+			Time:    time.Date(2016, time.September, 6, 22, 10, 0, 0, edt),
+			Message: "Power failure.",
+			Kind:    EventPowerFailure,
+		},
+		{
+			Time:    time.Date(2016, time.September, 6, 22, 10, 6, 0, edt),
+			Message: "Running on UPS batteries.",
+			Kind:    EventOnBattery,
+		},
+		{
+			Time:    time.Date(2016, time.September, 6, 22, 10, 30, 0, edt),
+			Message: "Mains returned. No longer on UPS batteries.",
+			Kind:    EventMainsReturned,
+		},
+		{
+			Message: "apcupsd exiting, signal 15",
+			Kind:    EventExit,
+		},
+	}
+
+	c := testClientCommand(t, "events", func() [][]byte {
+		var out [][]byte
+		for _, l := range lines {
+			lenb, lb := kvBytes(l)
+			out = append(out, lenb)
+			out = append(out, lb)
+		}
+
+		return out
+	})
+
+	got, err := c.Events()
+	if err != nil {
+		t.Fatalf("failed to retrieve events: %v", err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected Events (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientStatusContextTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to start listener: %v", err)
+	}
+	defer l.Close()
+
+	// Accept a connection but never respond, as a hung NIS would.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		<-done
+	}()
+
+	c, err := Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial Client: %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.StatusContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, but got: %v", err)
+	}
+
+	// The connection was closed to interrupt the request, so it cannot be
+	// reused.
+	if _, err := c.Status(); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("expected closed connection, but got: %v", err)
+	}
+}
+
+func TestClientStatusContextCanceledDuringResponse(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The context is canceled while the response is being read, but the
+	// response is still read in full.
+	rwc := &cancelRWC{replayRWC: newReplayRWC(dumpUSB), cancel: cancel}
+	c := New(rwc)
+
+	_, err := c.StatusContext(ctx)
+
+	// A request must fail if its connection was closed, and must not close
+	// the connection otherwise.
+	if rwc.isClosed() {
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context canceled, but got: %v", err)
+		}
+
+		return
+	}
+	if err != nil {
+		t.Fatalf("failed to retrieve status: %v", err)
+	}
+}
+
+func TestClientEventsContextCanceled(t *testing.T) {
+	c := New(&testReadWriterCloser{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := c.EventsContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, but got: %v", err)
+	}
+}
+
+// A cancelRWC is a replayRWC which cancels a context on its first read, and
+// records whether it was closed.
+type cancelRWC struct {
+	*replayRWC
+	cancel func()
+	once   sync.Once
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func (rwc *cancelRWC) Read(b []byte) (int, error) {
+	rwc.once.Do(func() {
+		rwc.cancel()
+
+		// Give the Client a chance to observe the cancelation.
+		time.Sleep(10 * time.Millisecond)
+	})
+
+	return rwc.replayRWC.Read(b)
+}
+
+func (rwc *cancelRWC) Close() error {
+	rwc.mu.Lock()
+	defer rwc.mu.Unlock()
+
+	rwc.closed = true
+	return nil
+}
+
+func (rwc *cancelRWC) isClosed() bool {
+	rwc.mu.Lock()
+	defer rwc.mu.Unlock()
+
+	return rwc.closed
+}
+
 func testClient(t *testing.T, fn func() [][]byte) *Client {
+	return testClientCommand(t, "status", fn)
+}
+
+func testClientCommand(t *testing.T, cmd string, fn func() [][]byte) *Client {
 	l, err := net.Listen("tcp", ":0")
 	if err != nil {
 		t.Fatalf("failed to start listener: %v", err)
@@ -124,8 +493,8 @@ func testClient(t *testing.T, fn func() [][]byte) *Client {
 			panicf("failed to read from connection: %v", err)
 		}
 
-		status := []byte{0, 6, 's', 't', 'a', 't', 'u', 's'}
-		if diff := cmp.Diff(status, in[:n]); diff != "" {
+		lenb, cmdb := kvBytes(cmd)
+		if diff := cmp.Diff(append(lenb, cmdb...), in[:n]); diff != "" {
 			panicf("unexpected Client request (-want +got):\n%s", diff)
 		}
 
@@ -0,0 +1,206 @@
+// Command apcaccess is a Go implementation of apcupsd's apcaccess utility,
+// which prints the status or event log of an apcupsd Network Information
+// Server (NIS), or the contents of an apcupsd status file.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mdlayher/apcupsd"
+)
+
+const (
+	// defaultHost is the default NIS address, matching apcaccess.
+	defaultHost = "localhost:3551"
+
+	// defaultPort is the default NIS port, used when -h omits a port.
+	defaultPort = "3551"
+
+	// timeout bounds the time spent dialing and querying a NIS.
+	timeout = 10 * time.Second
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "apcaccess: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run runs apcaccess with the command line arguments in args, writing output
+// to w.
+func run(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("apcaccess", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: apcaccess [-h host[:port]] [-f file] [-p KEY] [-u] [status|events]\n")
+		fs.PrintDefaults()
+	}
+
+	var (
+		host  = fs.String("h", defaultHost, "NIS `host[:port]` to query")
+		file  = fs.String("f", "", "read status from apcupsd status `file` instead of a NIS")
+		key   = fs.String("p", "", "print only the value of status `KEY`")
+		units = fs.Bool("u", false, "strip units from numeric values")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cmd := "status"
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		cmd = fs.Arg(0)
+	default:
+		fs.Usage()
+		return errors.New("too many arguments")
+	}
+
+	switch cmd {
+	case "status":
+		s, err := status(*host, *file)
+		if err != nil {
+			return err
+		}
+
+		return printStatus(w, s, *key, *units)
+	case "events":
+		if *file != "" {
+			return errors.New("events cannot be read from a status file")
+		}
+
+		events, err := events(*host)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range events {
+			if _, err := fmt.Fprintln(w, e); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+}
+
+// status retrieves the status from file if set, or from the NIS at host. Like
+// apcaccess, a status is printed even if some of its values are malformed, so
+// it is parsed leniently and any partially parsed status is returned.
+func status(host, file string) (*apcupsd.Status, error) {
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		d := apcupsd.NewDecoder(f)
+		d.ParseMode = apcupsd.ParseLenient
+
+		// An empty file holds an empty status.
+		var s apcupsd.Status
+		if err := d.DecodeInto(&s); err != nil && err != io.EOF && !partial(err) {
+			return nil, err
+		}
+
+		return &s, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := dial(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	c.ParseMode = apcupsd.ParseLenient
+
+	s, err := c.StatusContext(ctx)
+	if err != nil && !partial(err) {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// partial reports whether err leaves a partially parsed status which can still
+// be printed.
+func partial(err error) bool {
+	var (
+		perrs apcupsd.ParseErrors
+		ierr  *apcupsd.IncompleteStatusError
+	)
+
+	return errors.As(err, &perrs) || errors.As(err, &ierr)
+}
+
+// events retrieves the event log from the NIS at host.
+func events(host string) ([]apcupsd.Event, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := dial(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	return c.EventsContext(ctx)
+}
+
+// dial dials the NIS at host, adding the default port if none is specified.
+func dial(ctx context.Context, host string) (*apcupsd.Client, error) {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, defaultPort)
+	}
+
+	return apcupsd.DialContext(ctx, "tcp", host)
+}
+
+// printStatus prints the raw key/value pairs of s in the same format as
+// apcaccess, or only the value for key if set.
+func printStatus(w io.Writer, s *apcupsd.Status, key string, strip bool) error {
+	value := func(kv apcupsd.KeyValue) string {
+		// Only strip the unit from a value which was parsed along with it.
+		if strip && s.Has(kv.Key) && s.Unit(kv.Key) != apcupsd.UnitNone {
+			num, _, _ := strings.Cut(kv.Value, " ")
+			return num
+		}
+
+		return kv.Value
+	}
+
+	for _, kv := range s.Raw {
+		switch {
+		case key != "" && kv.Key != key:
+			continue
+		case key != "":
+			_, err := fmt.Fprintln(w, value(kv))
+			return err
+		default:
+			if _, err := fmt.Fprintf(w, "%-9s: %s\n", kv.Key, value(kv)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if key != "" {
+		return fmt.Errorf("key %q not found", key)
+	}
+
+	return nil
+}
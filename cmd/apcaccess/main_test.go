@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/apcupsd"
+)
+
+// statusFile's APC header is stale: it declares fewer records and bytes than
+// follow it, which must not prevent the status from being used.
+const statusFile = `APC      : 001,005,0140
+DATE     : 2016-09-06 22:13:28 -0400  
+HOSTNAME : example
+LINEV    : 121.0 Volts
+ALARMDEL : No alarm
+NOMPOWER : 865 Watts
+LOADPCT  : 13.0 Percent Load Capacity
+TONBATT  : 0 seconds
+CUMONBATT: 1.5 hours
+END APC  : 2016-09-06 22:13:49 -0400  
+`
+
+func TestRunStatusFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apcupsd.status")
+	if err := os.WriteFile(path, []byte(statusFile), 0o644); err != nil {
+		t.Fatalf("failed to write status file: %v", err)
+	}
+
+	tests := []struct {
+		desc string
+		args []string
+		out  string
+	}{
+		{
+			desc: "status",
+			args: []string{"-f", path},
+			out: `APC      : 001,005,0140
+DATE     : 2016-09-06 22:13:28 -0400
+HOSTNAME : example
+LINEV    : 121.0 Volts
+ALARMDEL : No alarm
+NOMPOWER : 865 Watts
+LOADPCT  : 13.0 Percent Load Capacity
+TONBATT  : 0 seconds
+CUMONBATT: 1.5 hours
+END APC  : 2016-09-06 22:13:49 -0400
+`,
+		},
+		{
+			desc: "strip units",
+			args: []string{"-f", path, "-u"},
+			out: `APC      : 001,005,0140
+DATE     : 2016-09-06 22:13:28 -0400
+HOSTNAME : example
+LINEV    : 121.0
+ALARMDEL : No alarm
+NOMPOWER : 865
+LOADPCT  : 13.0
+TONBATT  : 0
+CUMONBATT: 1.5
+END APC  : 2016-09-06 22:13:49 -0400
+`,
+		},
+		{
+			desc: "key",
+			args: []string{"-f", path, "-p", "LINEV"},
+			out:  "121.0 Volts\n",
+		},
+		{
+			desc: "key strip units",
+			args: []string{"-f", path, "-u", "-p", "NOMPOWER", "status"},
+			out:  "865\n",
+		},
+		{
+			desc: "key strip lowercase units",
+			args: []string{"-f", path, "-u", "-p", "TONBATT"},
+			out:  "0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := run(tt.args, &b); err != nil {
+				t.Fatalf("failed to run: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.out, b.String()); diff != "" {
+				t.Fatalf("unexpected output (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunStatusFileMalformed(t *testing.T) {
+	// Values which cannot be parsed must still be printed, as apcaccess does,
+	// and their units are not stripped.
+	const in = `HOSTNAME : example
+LINEV    : garbage Volts
+NUMXFERS : many
+not a key/value pair
+END APC  : 2016-09-06 22:13:49 -0400
+`
+
+	path := filepath.Join(t.TempDir(), "apcupsd.status")
+	if err := os.WriteFile(path, []byte(in), 0o644); err != nil {
+		t.Fatalf("failed to write status file: %v", err)
+	}
+
+	// Only key/value pairs are printed.
+	const out = `HOSTNAME : example
+LINEV    : garbage Volts
+NUMXFERS : many
+END APC  : 2016-09-06 22:13:49 -0400
+`
+
+	for _, args := range [][]string{{"-f", path}, {"-f", path, "-u"}} {
+		var b bytes.Buffer
+		if err := run(args, &b); err != nil {
+			t.Fatalf("failed to run: %v", err)
+		}
+
+		if diff := cmp.Diff(out, b.String()); diff != "" {
+			t.Fatalf("unexpected output (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestRunNIS(t *testing.T) {
+	// The stale header is reported, but the complete status is still returned.
+	s, err := apcupsd.ParseStatus(strings.NewReader(statusFile))
+	var ierr *apcupsd.IncompleteStatusError
+	if !errors.As(err, &ierr) || errors.Is(err, io.ErrUnexpectedEOF) || s == nil {
+		t.Fatalf("expected stale header error, but got: %v", err)
+	}
+
+	events := []apcupsd.Event{
+		{
+			Time:    time.Date(2016, time.September, 6, 22, 10, 0, 0, time.UTC),
+			Message: "Power failure.",
+		},
+		{
+			Message: "apcupsd exiting, signal 15",
+		},
+	}
+
+	addr := testNIS(t, &testProvider{s: s, events: events})
+
+	tests := []struct {
+		desc string
+		args []string
+		out  string
+	}{
+		{
+			desc: "key",
+			args: []string{"-h", addr, "-p", "HOSTNAME"},
+			out:  "example\n",
+		},
+		{
+			desc: "events",
+			args: []string{"-h", addr, "events"},
+			out:  "2016-09-06 22:10:00 +0000  Power failure.\napcupsd exiting, signal 15\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := run(tt.args, &b); err != nil {
+				t.Fatalf("failed to run: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.out, b.String()); diff != "" {
+				t.Fatalf("unexpected output (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		desc string
+		args []string
+	}{
+		{
+			desc: "unknown command",
+			args: []string{"-f", "/dev/null", "foo"},
+		},
+		{
+			desc: "too many arguments",
+			args: []string{"status", "events"},
+		},
+		{
+			desc: "events from file",
+			args: []string{"-f", "/dev/null", "events"},
+		},
+		{
+			desc: "key not found",
+			args: []string{"-f", "/dev/null", "-p", "LINEV"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := run(tt.args, &b); err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+		})
+	}
+}
+
+// testNIS starts an apcupsd.Server which serves data from p and returns its
+// address.
+func testNIS(t *testing.T, p apcupsd.Provider) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	srv := apcupsd.NewServer(p)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = srv.Serve(l)
+	}()
+
+	t.Cleanup(func() {
+		_ = srv.Close()
+		<-done
+	})
+
+	return l.Addr().String()
+}
+
+// A testProvider is an apcupsd.Provider which serves fixed data.
+type testProvider struct {
+	s      *apcupsd.Status
+	events []apcupsd.Event
+}
+
+func (p *testProvider) Status(_ context.Context) (*apcupsd.Status, error) { return p.s, nil }
+
+func (p *testProvider) Events(_ context.Context) ([]apcupsd.Event, error) { return p.events, nil }
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mdlayher/apcupsd"
+)
+
+// contentType is the content type of the Prometheus text exposition format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// A metric is a Prometheus metric derived from a Status. Samples are only
+// written for a UPS which reports key.
+type metric struct {
+	name, help, typ, key string
+	value                func(s *apcupsd.Status) float64
+}
+
+// metrics is the list of metrics exported for each UPS.
+var metrics = []metric{
+	{
+		name:  "apcupsd_line_volts",
+		help:  "Current line voltage.",
+		typ:   "gauge",
+		key:   "LINEV",
+		value: func(s *apcupsd.Status) float64 { return s.LineVoltage },
+	},
+	{
+		name:  "apcupsd_output_volts",
+		help:  "Current output voltage.",
+		typ:   "gauge",
+		key:   "OUTPUTV",
+		value: func(s *apcupsd.Status) float64 { return s.OutputVoltage },
+	},
+	{
+		name:  "apcupsd_ups_load_percent",
+		help:  "Current UPS load percentage.",
+		typ:   "gauge",
+		key:   "LOADPCT",
+		value: func(s *apcupsd.Status) float64 { return s.LoadPercent },
+	},
+	{
+		name:  "apcupsd_battery_charge_percent",
+		help:  "Current battery charge percentage.",
+		typ:   "gauge",
+		key:   "BCHARGE",
+		value: func(s *apcupsd.Status) float64 { return s.BatteryChargePercent },
+	},
+	{
+		name:  "apcupsd_battery_time_left_seconds",
+		help:  "Estimated remaining runtime on batteries in seconds.",
+		typ:   "gauge",
+		key:   "TIMELEFT",
+		value: func(s *apcupsd.Status) float64 { return s.TimeLeft.Seconds() },
+	},
+	{
+		name:  "apcupsd_battery_number_transfers_total",
+		help:  "Total number of transfers to batteries since apcupsd startup.",
+		typ:   "counter",
+		key:   "NUMXFERS",
+		value: func(s *apcupsd.Status) float64 { return float64(s.NumberTransfers) },
+	},
+	{
+		name:  "apcupsd_battery_cumulative_time_on_battery_seconds_total",
+		help:  "Total time spent on batteries in seconds since apcupsd startup.",
+		typ:   "counter",
+		key:   "CUMONBATT",
+		value: func(s *apcupsd.Status) float64 { return s.CumulativeTimeOnBattery.Seconds() },
+	},
+	{
+		name:  "apcupsd_nominal_power_watts",
+		help:  "Nominal power output in watts.",
+		typ:   "gauge",
+		key:   "NOMPOWER",
+		value: func(s *apcupsd.Status) float64 { return float64(s.NominalPower) },
+	},
+	{
+		name:  "apcupsd_internal_temperature_celsius",
+		help:  "Internal UPS temperature in degrees Celsius.",
+		typ:   "gauge",
+		key:   "ITEMP",
+		value: func(s *apcupsd.Status) float64 { return s.InternalTemp },
+	},
+}
+
+// A handler is an http.Handler which polls NISes and serves their metrics.
+type handler struct {
+	p     *apcupsd.Poller
+	addrs []string
+}
+
+// newHandler creates a handler which polls addrs using p.
+func newHandler(p *apcupsd.Poller, addrs []string) http.Handler {
+	// Sort addresses for stable output.
+	sorted := make([]string, len(addrs))
+	copy(sorted, addrs)
+	sort.Strings(sorted)
+
+	return &handler{
+		p:     p,
+		addrs: sorted,
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	results := h.p.Poll(r.Context(), h.addrs)
+
+	w.Header().Set("Content-Type", contentType)
+
+	// Headers are already written by the time an error could occur, so
+	// nothing more can be done if writing fails.
+	_ = writeMetrics(w, h.addrs, results)
+}
+
+// writeMetrics writes metrics for each address's result to w in the
+// Prometheus text exposition format.
+func writeMetrics(w io.Writer, addrs []string, results map[string]apcupsd.PollResult) error {
+	bw := bufio.NewWriter(w)
+
+	header(bw, "apcupsd_up", "Whether the NIS could be polled successfully.", "gauge")
+	for _, addr := range addrs {
+		var up float64
+		if status(results[addr]) != nil {
+			up = 1
+		}
+
+		sample(bw, "apcupsd_up", up, "ups", addr)
+	}
+
+	header(bw, "apcupsd_info", "Metadata about the UPS.", "gauge")
+	for _, addr := range addrs {
+		s := status(results[addr])
+		if s == nil {
+			continue
+		}
+
+		sample(bw, "apcupsd_info", 1,
+			"ups", addr,
+			"hostname", s.Hostname,
+			"ups_name", s.UPSName,
+			"model", s.Model,
+			"serial", s.SerialNumber,
+			"firmware", s.Firmware,
+			"version", s.Version,
+		)
+	}
+
+	for _, m := range metrics {
+		header(bw, m.name, m.help, m.typ)
+		for _, addr := range addrs {
+			// Omit metrics which the UPS does not report, rather than
+			// reporting a misleading zero value.
+			if s := status(results[addr]); s != nil && s.Has(m.key) {
+				sample(bw, m.name, m.value(s), "ups", addr)
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// status returns the Status from a PollResult, or nil if polling failed.
+func status(r apcupsd.PollResult) *apcupsd.Status {
+	switch ierr, ok := r.Err.(*apcupsd.IncompleteStatusError); {
+	case r.Err == nil:
+	case ok && !errors.Is(ierr, io.ErrUnexpectedEOF):
+		// A complete status whose APC header is stale is still usable.
+	default:
+		return nil
+	}
+
+	return r.Status
+}
+
+// header writes the HELP and TYPE lines for a metric.
+func header(w *bufio.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+}
+
+// sample writes a single sample for a metric with label name/value pairs.
+func sample(w *bufio.Writer, name string, value float64, labels ...string) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i := 0; i < len(labels); i += 2 {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+
+			fmt.Fprintf(w, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+		}
+		w.WriteByte('}')
+	}
+
+	w.WriteByte(' ')
+	w.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	w.WriteByte('\n')
+}
+
+// labelEscaper escapes label values as required by the Prometheus text
+// exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a label value.
+func escapeLabel(v string) string { return labelEscaper.Replace(v) }
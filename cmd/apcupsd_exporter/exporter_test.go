@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/apcupsd"
+)
+
+func TestHandler(t *testing.T) {
+	s := &apcupsd.Status{
+		Hostname:                "example",
+		UPSName:                 "ups\"1",
+		Version:                 "3.14.14 (31 May 2016) debian",
+		Model:                   "Back-UPS XS 1300G",
+		SerialNumber:            "3B1234X12345",
+		Firmware:                "880.R2 .D USB FW:R2",
+		LineVoltage:             121.5,
+		OutputVoltage:           120,
+		LoadPercent:             13,
+		BatteryChargePercent:    100,
+		TimeLeft:                46*time.Minute + 30*time.Second,
+		CumulativeTimeOnBattery: 30 * time.Second,
+		NominalPower:            865,
+
+		// The UPS reports zero transfers, but does not report its internal
+		// temperature, so no sample is written for it.
+		Raw: []apcupsd.KeyValue{{Key: "NUMXFERS", Value: "0"}},
+	}
+
+	good := testNIS(t, s)
+
+	// Find an address with nothing listening.
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	bad := l.Addr().String()
+	_ = l.Close()
+
+	p := apcupsd.NewPoller("tcp", &apcupsd.PollerConfig{Timeout: 5 * time.Second})
+	defer p.Close()
+
+	h := newHandler(p, []string{good, bad})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	if got := w.Header().Get("Content-Type"); got != contentType {
+		t.Fatalf("unexpected Content-Type: %q", got)
+	}
+
+	want := map[string]bool{
+		`apcupsd_up{ups="` + good + `"} 1`: true,
+		`apcupsd_up{ups="` + bad + `"} 0`:  true,
+		`apcupsd_info{ups="` + good + `",hostname="example",ups_name="ups\"1",model="Back-UPS XS 1300G",serial="3B1234X12345",firmware="880.R2 .D USB FW:R2",version="3.14.14 (31 May 2016) debian"} 1`: true,
+		`apcupsd_line_volts{ups="` + good + `"} 121.5`:                                    true,
+		`apcupsd_output_volts{ups="` + good + `"} 120`:                                    true,
+		`apcupsd_ups_load_percent{ups="` + good + `"} 13`:                                 true,
+		`apcupsd_battery_charge_percent{ups="` + good + `"} 100`:                          true,
+		`apcupsd_battery_time_left_seconds{ups="` + good + `"} 2790`:                      true,
+		`apcupsd_battery_number_transfers_total{ups="` + good + `"} 0`:                    true,
+		`apcupsd_battery_cumulative_time_on_battery_seconds_total{ups="` + good + `"} 30`: true,
+		`apcupsd_nominal_power_watts{ups="` + good + `"} 865`:                             true,
+	}
+
+	got := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(w.Body.String()), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		got[line] = true
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected metrics (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteMetricsIncompleteStatus(t *testing.T) {
+	s := &apcupsd.Status{
+		LineVoltage: 120,
+		Raw:         []apcupsd.KeyValue{{Key: "LINEV", Value: "120.0 Volts"}},
+	}
+
+	results := map[string]apcupsd.PollResult{
+		// A complete status with a stale header is still reported.
+		"stale": {
+			Status: s,
+			Err: &apcupsd.IncompleteStatusError{
+				Header:  apcupsd.Header{Format: 1, Records: 2, Bytes: 50},
+				Records: 3,
+				Bytes:   79,
+				EndAPC:  true,
+			},
+		},
+		"truncated": {
+			Status: s,
+			Err: &apcupsd.IncompleteStatusError{
+				Header:  apcupsd.Header{Format: 1, Records: 3, Bytes: 79},
+				Records: 2,
+				Bytes:   42,
+			},
+		},
+	}
+
+	var b strings.Builder
+	if err := writeMetrics(&b, []string{"stale", "truncated"}, results); err != nil {
+		t.Fatalf("failed to write metrics: %v", err)
+	}
+
+	want := map[string]bool{
+		`apcupsd_up{ups="stale"} 1`:     true,
+		`apcupsd_up{ups="truncated"} 0`: true,
+		`apcupsd_info{ups="stale",hostname="",ups_name="",model="",serial="",firmware="",version=""} 1`: true,
+		`apcupsd_line_volts{ups="stale"} 120`: true,
+	}
+
+	got := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		got[line] = true
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected metrics (-want +got):\n%s", diff)
+	}
+}
+
+// testNIS starts an apcupsd.Server which serves s and returns its address.
+func testNIS(t *testing.T, s *apcupsd.Status) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	srv := apcupsd.NewServer(staticProvider{s: s})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = srv.Serve(l)
+	}()
+
+	t.Cleanup(func() {
+		_ = srv.Close()
+		<-done
+	})
+
+	return l.Addr().String()
+}
+
+// A staticProvider is an apcupsd.Provider which serves a fixed Status.
+type staticProvider struct {
+	s *apcupsd.Status
+}
+
+func (p staticProvider) Status(_ context.Context) (*apcupsd.Status, error) { return p.s, nil }
+
+func (p staticProvider) Events(_ context.Context) ([]apcupsd.Event, error) { return nil, nil }
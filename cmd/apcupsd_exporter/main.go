@@ -0,0 +1,47 @@
+// Command apcupsd_exporter polls one or more apcupsd Network Information
+// Servers (NIS) and exposes UPS metrics in the Prometheus text exposition
+// format.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mdlayher/apcupsd"
+)
+
+func main() {
+	var (
+		listen  = flag.String("listen", ":9162", "address on which to serve metrics over HTTP")
+		path    = flag.String("path", "/metrics", "HTTP path at which metrics are served")
+		targets = flag.String("targets", "localhost:3551", "comma-separated list of NIS addresses to poll")
+		timeout = flag.Duration("timeout", 5*time.Second, "timeout for polling each NIS")
+	)
+
+	flag.Parse()
+
+	var addrs []string
+	for _, addr := range strings.Split(*targets, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	if len(addrs) == 0 {
+		log.Fatal("at least one NIS address must be specified with -targets")
+	}
+
+	p := apcupsd.NewPoller("tcp", &apcupsd.PollerConfig{Timeout: *timeout})
+	defer p.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle(*path, newHandler(p, addrs))
+
+	log.Printf("starting apcupsd exporter on %q for NIS: %s", *listen, strings.Join(addrs, ", "))
+
+	if err := http.ListenAndServe(*listen, mux); err != nil {
+		log.Fatalf("failed to serve HTTP: %v", err)
+	}
+}
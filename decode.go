@@ -0,0 +1,134 @@
+package apcupsd
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// A Decoder reads and decodes a stream of statuses in the text format
+// accepted by ParseStatus, such as repeated apcaccess output. Each status ends
+// with its END APC record.
+//
+// A Decoder reuses its buffers between calls to DecodeInto, so that decoding
+// into an existing Status allocates at most the text of its key/value pairs.
+type Decoder struct {
+	// ParseMode specifies how DecodeInto handles malformed and unknown
+	// key/value pairs. The zero value is ParseFailFast.
+	ParseMode ParseMode
+
+	br   *bufio.Reader
+	line []byte
+	sd   statusDecoder
+}
+
+// NewDecoder creates a Decoder which reads statuses from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{br: bufio.NewReader(r)}
+}
+
+// DecodeInto decodes the next status from the stream into s, replacing its
+// contents. The backing array of s.Raw is reused, so any copy of s.Raw made
+// before the call may be overwritten.
+//
+// If the stream ends before another status begins, io.EOF is returned. Parse
+// errors are handled according to the Decoder's ParseMode, and the contents of
+// s after an error are as described for the Status returned by Client.Status.
+// When no Status would be returned, they are unspecified.
+func (d *Decoder) DecodeInto(s *Status) error {
+	d.sd.reset()
+
+	var data bool
+	for {
+		line, err := d.readLine()
+		if len(line) > 0 {
+			d.sd.add(line)
+			data = data || len(bytes.TrimSpace(line)) > 0
+		}
+
+		switch {
+		case err == io.EOF && !data:
+			return io.EOF
+		case err == io.EOF:
+			// The final status need not end with a newline or END APC.
+		case err != nil:
+			return err
+		case !isEndAPC(line):
+			continue
+		}
+
+		_, err = d.sd.decode(s, d.ParseMode)
+		return err
+	}
+}
+
+// readLine reads the next line from the stream, including its newline. The
+// returned slice is only valid until the next call.
+func (d *Decoder) readLine() ([]byte, error) {
+	line, err := d.br.ReadSlice('\n')
+	if err != bufio.ErrBufferFull {
+		return line, err
+	}
+
+	// The line is longer than the bufio.Reader's buffer, so gather it up.
+	d.line = append(d.line[:0], line...)
+	for err == bufio.ErrBufferFull {
+		line, err = d.br.ReadSlice('\n')
+		d.line = append(d.line, line...)
+	}
+
+	return d.line, err
+}
+
+// isEndAPC reports whether record is the END APC record which ends a status.
+func isEndAPC(record []byte) bool {
+	k, _, ok := bytes.Cut(record, []byte(":"))
+	return ok && string(bytes.TrimSpace(k)) == string(keyEndAPC)
+}
+
+// A statusDecoder buffers the records of a single status and parses them into
+// a Status.
+//
+// Parsing is deferred until every record has been added so that every string
+// in the Status can share a single allocation, which is skipped entirely when
+// the text is unchanged since the previous status.
+type statusDecoder struct {
+	buf  []byte
+	ends []int
+	text string
+}
+
+// reset discards any buffered records.
+func (d *statusDecoder) reset() {
+	d.buf = d.buf[:0]
+	d.ends = d.ends[:0]
+}
+
+// add buffers a single record.
+func (d *statusDecoder) add(record []byte) {
+	d.buf = append(d.buf, record...)
+	d.ends = append(d.ends, len(d.buf))
+}
+
+// decode parses the buffered records into s, replacing its contents but
+// reusing the backing array of s.Raw. It returns s and any error in the same
+// manner as statusParser.result.
+func (d *statusDecoder) decode(s *Status, mode ParseMode) (*Status, error) {
+	if string(d.buf) != d.text {
+		d.text = string(d.buf)
+	}
+
+	*s = Status{Raw: s.Raw[:0]}
+	p := newStatusParser(s, mode)
+
+	var start int
+	for _, end := range d.ends {
+		if err := p.parse(d.text[start:end]); err != nil {
+			return nil, err
+		}
+
+		start = end
+	}
+
+	return p.result()
+}
@@ -0,0 +1,206 @@
+package apcupsd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDecoderDecodeInto(t *testing.T) {
+	dumps := []string{dumpUSB, dumpSNMP, dumpPCNET, dumpModbus, dumpNet}
+
+	// Blank lines between statuses must be tolerated.
+	d := NewDecoder(strings.NewReader(strings.Join(dumps, "\n")))
+
+	// Every status is decoded into the same Status, which must not retain
+	// any fields from the previous one.
+	var s Status
+	for i, dump := range dumps {
+		want, err := ParseStatus(strings.NewReader(dump))
+		if err != nil {
+			t.Fatalf("failed to parse status %d: %v", i, err)
+		}
+
+		if err := d.DecodeInto(&s); err != nil {
+			t.Fatalf("failed to decode status %d: %v", i, err)
+		}
+
+		if diff := cmp.Diff(want, &s); diff != "" {
+			t.Fatalf("unexpected Status %d (-want +got):\n%s", i, diff)
+		}
+	}
+
+	if err := d.DecodeInto(&s); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected EOF, but got: %v", err)
+	}
+}
+
+func TestDecoderDecodeIntoNoEndAPC(t *testing.T) {
+	// A line longer than the Decoder's read buffer must also be handled.
+	long := strings.Repeat("x", 8192)
+
+	d := NewDecoder(strings.NewReader("HOSTNAME : example\nFOO      : " + long))
+
+	var s Status
+	if err := d.DecodeInto(&s); err != nil {
+		t.Fatalf("failed to decode status: %v", err)
+	}
+
+	want := &Status{
+		Hostname: "example",
+		Raw: []KeyValue{
+			{Key: "HOSTNAME", Value: "example"},
+			{Key: "FOO", Value: long},
+		},
+	}
+
+	if diff := cmp.Diff(want, &s); diff != "" {
+		t.Fatalf("unexpected Status (-want +got):\n%s", diff)
+	}
+
+	if err := d.DecodeInto(&s); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected EOF, but got: %v", err)
+	}
+}
+
+func TestDecoderDecodeIntoErrors(t *testing.T) {
+	const in = `HOSTNAME : example
+NUMXFERS : many
+END APC  : 2016-09-06 22:13:49 -0400
+HOSTNAME : next
+END APC  : 2016-09-06 22:13:50 -0400
+`
+
+	d := NewDecoder(strings.NewReader(in))
+	d.ParseMode = ParseLenient
+
+	var s Status
+	var errs ParseErrors
+	if err := d.DecodeInto(&s); !errors.As(err, &errs) || len(errs) != 1 || errs[0].Key != "NUMXFERS" {
+		t.Fatalf("expected NUMXFERS parse error, but got: %v", err)
+	}
+	if s.Hostname != "example" {
+		t.Fatalf("expected partial status, but got: %#v", s)
+	}
+
+	// An error must not affect the next status.
+	if err := d.DecodeInto(&s); err != nil {
+		t.Fatalf("failed to decode status: %v", err)
+	}
+	if s.Hostname != "next" {
+		t.Fatalf("unexpected hostname: %q", s.Hostname)
+	}
+}
+
+func BenchmarkClientStatus(b *testing.B) {
+	c := New(newReplayRWC(dumpUSB))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Status(); err != nil {
+			b.Fatalf("failed to retrieve status: %v", err)
+		}
+	}
+}
+
+func BenchmarkClientStatusInto(b *testing.B) {
+	tests := []struct {
+		name  string
+		dumps []string
+	}{
+		{
+			name:  "unchanged",
+			dumps: []string{dumpUSB},
+		},
+		{
+			name:  "changed",
+			dumps: []string{dumpUSB, dumpSNMP},
+		},
+	}
+
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			c := New(newReplayRWC(tt.dumps...))
+			ctx := context.Background()
+
+			var s Status
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if err := c.StatusInto(ctx, &s); err != nil {
+					b.Fatalf("failed to retrieve status: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParseStatus(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseStatus(strings.NewReader(dumpUSB)); err != nil {
+			b.Fatalf("failed to parse status: %v", err)
+		}
+	}
+}
+
+func BenchmarkDecoderDecodeInto(b *testing.B) {
+	r := strings.NewReader(dumpUSB)
+	d := NewDecoder(r)
+
+	var s Status
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r.Reset(dumpUSB)
+		if err := d.DecodeInto(&s); err != nil {
+			b.Fatalf("failed to decode status: %v", err)
+		}
+	}
+}
+
+// A replayRWC is an io.ReadWriteCloser which responds to each command written
+// to it with the next of a series of NIS status responses, in a loop.
+type replayRWC struct {
+	resps [][]byte
+	i     int
+	r     bytes.Reader
+}
+
+// newReplayRWC creates a replayRWC which responds with each dump in turn.
+func newReplayRWC(dumps ...string) *replayRWC {
+	var rwc replayRWC
+	for _, d := range dumps {
+		var recs []string
+		for _, l := range strings.SplitAfter(d, "\n") {
+			if l != "" {
+				recs = append(recs, l)
+			}
+		}
+
+		// End of response.
+		recs = append(recs, "")
+		rwc.resps = append(rwc.resps, frames(recs...))
+	}
+
+	return &rwc
+}
+
+func (rwc *replayRWC) Read(b []byte) (int, error) { return rwc.r.Read(b) }
+func (rwc *replayRWC) Close() error               { return nil }
+
+func (rwc *replayRWC) Write(b []byte) (int, error) {
+	rwc.r.Reset(rwc.resps[rwc.i%len(rwc.resps)])
+	rwc.i++
+	return len(b), nil
+}
@@ -0,0 +1,191 @@
+package apcupsd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Errors which describe why a key/value pair could not be parsed. They are
+// always wrapped by a ParseError, for use with errors.Is.
+var (
+	// ErrInvalidKeyValuePair indicates that a record is not in the expected
+	// "key : value" format.
+	ErrInvalidKeyValuePair = errors.New("invalid key/value pair")
+
+	// ErrInvalidDuration indicates that a value is not in the expected
+	// duration format, e.g. "10 Seconds" or "2 minutes".
+	ErrInvalidDuration = errors.New("invalid time duration")
+
+	// ErrInvalidTime indicates that a value is not in any of the known time
+	// formats, e.g. "2006-01-02 15:04:05 -0700" or "01/02/06".
+	ErrInvalidTime = errors.New("invalid time")
+
+	// ErrInvalidUnit indicates that a value's unit is unknown or is not valid
+	// for its key, e.g. "120.0 Percent" for LINEV.
+	ErrInvalidUnit = errors.New("invalid unit")
+
+	// ErrInvalidHeader indicates that the APC header record is not in the
+	// expected format, e.g. "001,036,0875".
+	ErrInvalidHeader = errors.New("invalid APC header")
+
+	// ErrUnknownKey indicates that a key/value pair does not correspond to
+	// any Status field while parsing with ParseStrict.
+	ErrUnknownKey = errors.New("unknown key")
+)
+
+// Errors which describe why a record violates the NIS protocol. They are
+// always wrapped by a ProtocolError, for use with errors.Is.
+var (
+	// ErrRecordTooLarge indicates that a record's length exceeds the maximum
+	// record size.
+	ErrRecordTooLarge = errors.New("apcupsd: record too large")
+
+	// ErrRecordTruncated indicates that the connection ended partway through
+	// a record.
+	ErrRecordTruncated = errors.New("apcupsd: record truncated")
+
+	// ErrRecordMalformed indicates that a record contains binary data rather
+	// than text.
+	ErrRecordMalformed = errors.New("apcupsd: record malformed")
+)
+
+// ErrBufferTooLarge is returned when Conn.WriteRecord is passed a buffer that
+// is too large to send in a single record.
+var ErrBufferTooLarge = errors.New("apcupsd: buffer too large; must be size of uint16 or less")
+
+// A ParseError is an error which occurred while parsing a single key/value
+// pair.
+type ParseError struct {
+	// The key of the pair. Empty if the pair could not be split into a key
+	// and value.
+	Key string
+	// The raw value of the pair, or the entire input if it could not be split
+	// into a key and value.
+	Value string
+	// The underlying error, such as ErrInvalidDuration or a *strconv.NumError.
+	Err error
+}
+
+// Error implements error.
+func (e *ParseError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("apcupsd: failed to parse %q: %v", e.Value, e.Err)
+	}
+
+	return fmt.Sprintf("apcupsd: failed to parse %s value %q: %v", e.Key, e.Value, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// ParseErrors is a list of every ParseError which occurred while parsing a
+// Status with ParseLenient or ParseStrict.
+type ParseErrors []*ParseError
+
+// Error implements error.
+func (e ParseErrors) Error() string {
+	strs := make([]string, 0, len(e))
+	for _, err := range e {
+		strs = append(strs, err.Error())
+	}
+
+	return fmt.Sprintf("apcupsd: %d errors parsing status: %s", len(e), strings.Join(strs, "; "))
+}
+
+// Unwrap returns each ParseError, for use with errors.Is and errors.As.
+func (e ParseErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// A ProtocolError is returned when data received from a NIS violates its
+// protocol. The connection cannot be used after a ProtocolError occurs.
+type ProtocolError struct {
+	// The length of the offending record, as indicated by its length prefix,
+	// or zero if the length prefix itself was truncated.
+	Length int
+	// The underlying error, such as ErrRecordTooLarge.
+	Err error
+}
+
+// Error implements error.
+func (e *ProtocolError) Error() string {
+	return fmt.Sprintf("%v (length: %d)", e.Err, e.Length)
+}
+
+// Unwrap returns the underlying error.
+func (e *ProtocolError) Unwrap() error { return e.Err }
+
+// A TruncatedResponseError is returned when the connection to a NIS ends
+// before the NIS indicates the end of its response, such as when apcupsd is
+// restarted while responding. It unwraps to io.ErrUnexpectedEOF.
+type TruncatedResponseError struct {
+	// The command sent to the NIS.
+	Command string
+	// The number of records received before the response ended.
+	Records int
+}
+
+// Error implements error.
+func (e *TruncatedResponseError) Error() string {
+	return fmt.Sprintf("apcupsd: %q response truncated after %d records", e.Command, e.Records)
+}
+
+// Unwrap returns io.ErrUnexpectedEOF.
+func (e *TruncatedResponseError) Unwrap() error { return io.ErrUnexpectedEOF }
+
+// An IncompleteStatusError is returned when a status does not match the
+// record and byte counts declared by its APC header or has no END APC record.
+//
+// If the END APC record is missing or fewer records were received than
+// declared, such as when apcupsd truncates its output while it is being
+// written, the status was truncated and the error unwraps to
+// io.ErrUnexpectedEOF. Otherwise, the status is complete but its header is
+// stale or inaccurate, and the error is returned along with the Status.
+type IncompleteStatusError struct {
+	// The header which began the status.
+	Header Header
+	// The number of records and bytes which followed the header.
+	Records, Bytes int
+	// Whether the END APC record was present.
+	EndAPC bool
+}
+
+// Error implements error.
+func (e *IncompleteStatusError) Error() string {
+	var end string
+	if !e.EndAPC {
+		end = " and no END APC record"
+	}
+
+	return fmt.Sprintf("apcupsd: incomplete status: header declares %d records of %d bytes, but got %d records of %d bytes%s",
+		e.Header.Records, e.Header.Bytes, e.Records, e.Bytes, end)
+}
+
+// Unwrap returns io.ErrUnexpectedEOF if the status was truncated.
+func (e *IncompleteStatusError) Unwrap() error {
+	if e.truncated() {
+		return io.ErrUnexpectedEOF
+	}
+
+	return nil
+}
+
+// truncated reports whether the status was cut short, rather than merely not
+// matching the counts in its header.
+func (e *IncompleteStatusError) truncated() bool {
+	return !e.EndAPC || e.Records < e.Header.Records
+}
+
+// isStaleHeader reports whether err only indicates that a complete status did
+// not match its APC header. Such a status is still usable.
+func isStaleHeader(err error) bool {
+	ierr, ok := err.(*IncompleteStatusError)
+	return ok && !ierr.truncated()
+}
@@ -0,0 +1,176 @@
+package apcupsd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		desc  string
+		recs  []string
+		end   bool
+		check func(t *testing.T, err error)
+	}{
+		{
+			desc: "truncated response",
+			recs: []string{"HOSTNAME : example\n", "LINEV    : 120.0 Volts\n"},
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, io.ErrUnexpectedEOF) {
+					t.Fatalf("expected unexpected EOF, but got: %v", err)
+				}
+
+				var terr *TruncatedResponseError
+				if !errors.As(err, &terr) {
+					t.Fatalf("expected TruncatedResponseError, but got: %v", err)
+				}
+
+				want := &TruncatedResponseError{Command: "status", Records: 2}
+				if diff := cmp.Diff(want, terr); diff != "" {
+					t.Fatalf("unexpected TruncatedResponseError (-want +got):\n%s", diff)
+				}
+			},
+		},
+		{
+			desc: "parse error",
+			recs: []string{"HOSTNAME : example\n", "TIMELEFT : soon\n"},
+			end:  true,
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, ErrInvalidDuration) {
+					t.Fatalf("expected invalid duration, but got: %v", err)
+				}
+
+				var perr *ParseError
+				if !errors.As(err, &perr) {
+					t.Fatalf("expected ParseError, but got: %v", err)
+				}
+
+				want := &ParseError{Key: "TIMELEFT", Value: "soon", Err: ErrInvalidDuration}
+				if diff := cmp.Diff(want.Error(), perr.Error()); diff != "" {
+					t.Fatalf("unexpected ParseError (-want +got):\n%s", diff)
+				}
+			},
+		},
+		{
+			desc: "protocol error",
+			recs: []string{"HOSTNAME : \x00\x01\x02\n"},
+			end:  true,
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, ErrRecordMalformed) {
+					t.Fatalf("expected malformed record, but got: %v", err)
+				}
+
+				var perr *ProtocolError
+				if !errors.As(err, &perr) || perr.Length != 15 {
+					t.Fatalf("expected ProtocolError, but got: %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			c := testPipeClient(t, tt.recs, tt.end)
+
+			_, err := c.Status()
+			tt.check(t, err)
+		})
+	}
+}
+
+func Test_isFinal(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		desc  string
+		ctx   context.Context
+		err   error
+		final bool
+	}{
+		{
+			desc: "network",
+			ctx:  context.Background(),
+			err:  &net.OpError{Op: "read", Err: io.ErrClosedPipe},
+		},
+		{
+			desc: "truncated",
+			ctx:  context.Background(),
+			err:  &TruncatedResponseError{Command: "status"},
+		},
+		{
+			desc: "protocol",
+			ctx:  context.Background(),
+			err:  &ProtocolError{Err: ErrRecordTooLarge},
+		},
+		{
+			desc:  "parse",
+			ctx:   context.Background(),
+			err:   &ParseError{Key: "TIMELEFT", Err: ErrInvalidDuration},
+			final: true,
+		},
+		{
+			desc:  "parse lenient",
+			ctx:   context.Background(),
+			err:   ParseErrors{{Key: "TIMELEFT", Err: ErrInvalidDuration}},
+			final: true,
+		},
+		{
+			desc:  "canceled",
+			ctx:   canceled,
+			err:   io.ErrUnexpectedEOF,
+			final: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if diff := cmp.Diff(tt.final, isFinal(tt.ctx, tt.err)); diff != "" {
+				t.Fatalf("unexpected final (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// testPipeClient creates a Client which receives recs in response to a single
+// command, followed by the end of the response if end is true, before the
+// connection is closed.
+func testPipeClient(t *testing.T, recs []string, end bool) *Client {
+	t.Helper()
+
+	c1, c2 := net.Pipe()
+	c := New(c1)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer c2.Close()
+
+		nc := NewConn(c2)
+		if _, err := nc.ReadRecord(make([]byte, maxString)); err != nil {
+			return
+		}
+
+		for _, r := range recs {
+			if err := nc.WriteRecord([]byte(r)); err != nil {
+				return
+			}
+		}
+
+		if end {
+			_ = nc.WriteRecord(nil)
+		}
+	}()
+
+	t.Cleanup(func() {
+		_ = c.Close()
+		<-done
+	})
+
+	return c
+}
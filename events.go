@@ -0,0 +1,186 @@
+package apcupsd
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An Event is a single entry in the apcupsd event log, as returned by a NIS.
+type Event struct {
+	// The date and time that the event occurred, or the zero time.Time if the
+	// log entry did not begin with a timestamp.
+	Time time.Time
+	// The event message as written to the log by apcupsd.
+	Message string
+	// The kind of event, as classified from Message.
+	Kind EventKind
+}
+
+// An EventKind classifies an Event by the condition it describes.
+type EventKind int
+
+// Possible EventKind values. Message text is copied from apcupsd source code,
+// v3.14.14.
+const (
+	// The event could not be classified.
+	EventUnknown EventKind = iota
+	// "Power failure."
+	EventPowerFailure
+	// "Running on UPS batteries."
+	EventOnBattery
+	// "Mains returned. No longer on UPS batteries." or
+	// "Power is back. UPS running on mains."
+	EventMainsReturned
+	// "Battery power exhausted.", "Battery charge below low limit.",
+	// "Reached run time limit on batteries." or
+	// "Reached remaining time percentage limit on batteries."
+	EventLowBattery
+	// "Initiating system shutdown!", "Remote Shutdown.",
+	// "Emergency Shutdown. Possible UPS battery failure." or
+	// "Users requested to logout".
+	EventShutdown
+	// "UPS battery must be replaced."
+	EventReplaceBattery
+	// "Communications with UPS lost."
+	EventCommLost
+	// "Communications with UPS restored."
+	EventCommRestored
+	// "UPS Self Test switch to battery."
+	EventSelftestStarted
+	// "UPS Self Test completed."
+	EventSelftestCompleted
+	// "Battery disconnected."
+	EventBatteryDisconnected
+	// "Battery reattached."
+	EventBatteryReattached
+	// "apcupsd ... startup succeeded"
+	EventStartup
+	// "apcupsd exiting, signal ..." or "apcupsd shutdown succeeded"
+	EventExit
+)
+
+// String returns the string representation of an EventKind.
+func (k EventKind) String() string {
+	switch k {
+	case EventUnknown:
+		return "unknown"
+	case EventPowerFailure:
+		return "power failure"
+	case EventOnBattery:
+		return "on battery"
+	case EventMainsReturned:
+		return "mains returned"
+	case EventLowBattery:
+		return "low battery"
+	case EventShutdown:
+		return "shutdown"
+	case EventReplaceBattery:
+		return "replace battery"
+	case EventCommLost:
+		return "communications lost"
+	case EventCommRestored:
+		return "communications restored"
+	case EventSelftestStarted:
+		return "self test started"
+	case EventSelftestCompleted:
+		return "self test completed"
+	case EventBatteryDisconnected:
+		return "battery disconnected"
+	case EventBatteryReattached:
+		return "battery reattached"
+	case EventStartup:
+		return "startup"
+	case EventExit:
+		return "exit"
+	default:
+		return "EventKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// eventPrefixes maps the beginning of an event log message to its EventKind.
+var eventPrefixes = []struct {
+	prefix string
+	kind   EventKind
+}{
+	{prefix: "Power failure", kind: EventPowerFailure},
+	{prefix: "Running on UPS batteries", kind: EventOnBattery},
+	{prefix: "Mains returned", kind: EventMainsReturned},
+	{prefix: "Power is back", kind: EventMainsReturned},
+	{prefix: "Battery power exhausted", kind: EventLowBattery},
+	{prefix: "Battery charge below low limit", kind: EventLowBattery},
+	{prefix: "Reached run time limit", kind: EventLowBattery},
+	{prefix: "Reached remaining time percentage limit", kind: EventLowBattery},
+	{prefix: "Initiating system shutdown", kind: EventShutdown},
+	{prefix: "Remote Shutdown", kind: EventShutdown},
+	{prefix: "Emergency Shutdown", kind: EventShutdown},
+	{prefix: "Users requested to logout", kind: EventShutdown},
+	{prefix: "UPS battery must be replaced", kind: EventReplaceBattery},
+	{prefix: "Communications with UPS lost", kind: EventCommLost},
+	{prefix: "Communications with UPS restored", kind: EventCommRestored},
+	{prefix: "UPS Self Test switch to battery", kind: EventSelftestStarted},
+	{prefix: "UPS Self Test completed", kind: EventSelftestCompleted},
+	{prefix: "Battery disconnected", kind: EventBatteryDisconnected},
+	{prefix: "Battery reattached", kind: EventBatteryReattached},
+	{prefix: "apcupsd exiting", kind: EventExit},
+}
+
+// parseEvent parses a single line of the apcupsd event log into an Event.
+func parseEvent(line string) Event {
+	line = strings.TrimSpace(line)
+
+	// Most lines begin with a timestamp in one of timeFormats, separated from
+	// the message by two spaces, but some (such as those written when apcupsd
+	// exits) carry no timestamp. Older timestamps may themselves contain two
+	// spaces, such as "Sat Aug  9 15:28:49 EDT 2008", so each separator is
+	// tried in turn.
+	var e Event
+	for off := 0; ; off += 2 {
+		i := strings.Index(line[off:], "  ")
+		if i < 0 {
+			break
+		}
+		off += i
+
+		if t, err := parseOptionalTime(line[:off]); err == nil && !t.IsZero() {
+			e.Time = t
+			line = strings.TrimSpace(line[off:])
+			break
+		}
+	}
+
+	e.Message = line
+	e.Kind = classifyEvent(line)
+	return e
+}
+
+// String returns the Event formatted as a line in the apcupsd event log.
+func (e Event) String() string {
+	if e.Time.IsZero() {
+		return e.Message
+	}
+
+	return e.Time.Format(timeFormatLong) + "  " + e.Message
+}
+
+// classifyEvent determines the EventKind for an event log message.
+func classifyEvent(msg string) EventKind {
+	for _, p := range eventPrefixes {
+		if strings.HasPrefix(msg, p.prefix) {
+			return p.kind
+		}
+	}
+
+	// Startup and shutdown messages include the apcupsd version and platform
+	// between fixed text, so they must be matched separately.
+	if strings.HasPrefix(msg, "apcupsd") {
+		switch {
+		case strings.HasSuffix(msg, "startup succeeded"):
+			return EventStartup
+		case strings.HasSuffix(msg, "shutdown succeeded"):
+			return EventExit
+		}
+	}
+
+	return EventUnknown
+}
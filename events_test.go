@@ -0,0 +1,128 @@
+package apcupsd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_parseEvent(t *testing.T) {
+	tests := []struct {
+		desc string
+		line string
+		e    Event
+	}{
+		{
+			desc: "unknown",
+			line: "2016-09-06 22:13:28 -0400  Something unexpected happened.",
+			e: Event{
+				Time:    time.Date(2016, time.September, 6, 22, 13, 28, 0, time.FixedZone("EDT", -60*60*4)),
+				Message: "Something unexpected happened.",
+			},
+		},
+		{
+			desc: "unix date",
+			line: "Sat Aug 09 15:28:49 EDT 2008  Power failure.",
+			e: Event{
+				Time:    time.Date(2008, time.August, 9, 15, 28, 49, 0, time.FixedZone("EDT", -60*60*4)),
+				Message: "Power failure.",
+				Kind:    EventPowerFailure,
+			},
+		},
+		{
+			desc: "unix date padded day",
+			line: "Sat Aug  9 15:28:49 CEST 2008  Running on UPS batteries.",
+			e: Event{
+				Time:    time.Date(2008, time.August, 9, 15, 28, 49, 0, time.FixedZone("CEST", 60*60*2)),
+				Message: "Running on UPS batteries.",
+				Kind:    EventOnBattery,
+			},
+		},
+		{
+			desc: "ansic",
+			line: "Sat Aug  9 15:28:49 2008  Mains returned. No longer on UPS batteries.",
+			e: Event{
+				Time:    time.Date(2008, time.August, 9, 15, 28, 49, 0, time.Local),
+				Message: "Mains returned. No longer on UPS batteries.",
+				Kind:    EventMainsReturned,
+			},
+		},
+		{
+			desc: "no time zone",
+			line: "2016-09-06 22:13:28  Power failure.",
+			e: Event{
+				Time:    time.Date(2016, time.September, 6, 22, 13, 28, 0, time.Local),
+				Message: "Power failure.",
+				Kind:    EventPowerFailure,
+			},
+		},
+		{
+			desc: "unknown time zone",
+			line: "Sat Aug 09 15:28:49 XYZ 2008  Power failure.",
+			e: Event{
+				Message: "Sat Aug 09 15:28:49 XYZ 2008  Power failure.",
+			},
+		},
+		{
+			desc: "no timestamp",
+			line: "apcupsd shutdown succeeded\n",
+			e: Event{
+				Message: "apcupsd shutdown succeeded",
+				Kind:    EventExit,
+			},
+		},
+		{
+			desc: "short line",
+			line: "Power failure.",
+			e: Event{
+				Message: "Power failure.",
+				Kind:    EventPowerFailure,
+			},
+		},
+		{
+			desc: "power back",
+			line: "2020-04-27 10:00:00 +0000  Power is back. UPS running on mains.",
+			e: Event{
+				Time:    time.Date(2020, time.April, 27, 10, 0, 0, 0, time.UTC),
+				Message: "Power is back. UPS running on mains.",
+				Kind:    EventMainsReturned,
+			},
+		},
+		{
+			desc: "self test started",
+			line: "2020-04-27 10:00:00 +0000  UPS Self Test switch to battery.",
+			e: Event{
+				Time:    time.Date(2020, time.April, 27, 10, 0, 0, 0, time.UTC),
+				Message: "UPS Self Test switch to battery.",
+				Kind:    EventSelftestStarted,
+			},
+		},
+		{
+			desc: "self test completed",
+			line: "2020-04-27 10:00:08 +0000  UPS Self Test completed: Battery OK",
+			e: Event{
+				Time:    time.Date(2020, time.April, 27, 10, 0, 8, 0, time.UTC),
+				Message: "UPS Self Test completed: Battery OK",
+				Kind:    EventSelftestCompleted,
+			},
+		},
+		{
+			desc: "comm lost",
+			line: "2020-04-27 10:00:00 +0000  Communications with UPS lost.",
+			e: Event{
+				Time:    time.Date(2020, time.April, 27, 10, 0, 0, 0, time.UTC),
+				Message: "Communications with UPS lost.",
+				Kind:    EventCommLost,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if diff := cmp.Diff(tt.e, parseEvent(tt.line)); diff != "" {
+				t.Fatalf("unexpected Event (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
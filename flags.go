@@ -0,0 +1,132 @@
+package apcupsd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A StatusFlag is a bitmask of UPS status flags, as reported by a NIS in the
+// STATFLAG field.
+type StatusFlag uint32
+
+// Possible StatusFlag values. Values copied from apcupsd source code,
+// v3.14.14.
+const (
+	// Runtime calibration is in progress.
+	StatusFlagCalibration StatusFlag = 0x00000001
+	// The UPS is trimming (lowering) a high line voltage.
+	StatusFlagTrim StatusFlag = 0x00000002
+	// The UPS is boosting (raising) a low line voltage.
+	StatusFlagBoost StatusFlag = 0x00000004
+	// The UPS is running on line power.
+	StatusFlagOnline StatusFlag = 0x00000008
+	// The UPS is running on batteries.
+	StatusFlagOnBattery StatusFlag = 0x00000010
+	// The UPS output is overloaded.
+	StatusFlagOverload StatusFlag = 0x00000020
+	// The UPS battery charge is low.
+	StatusFlagBatteryLow StatusFlag = 0x00000040
+	// The UPS battery must be replaced.
+	StatusFlagReplaceBattery StatusFlag = 0x00000080
+
+	// Extended flags added by apcupsd.
+
+	// Communications with the UPS have been lost.
+	StatusFlagCommLost StatusFlag = 0x00000100
+	// A system shutdown is in progress.
+	StatusFlagShutdown StatusFlag = 0x00000200
+	// apcupsd is running as a slave of another apcupsd instance.
+	StatusFlagSlave StatusFlag = 0x00000400
+	// A slave apcupsd instance is not responding.
+	StatusFlagSlaveDown StatusFlag = 0x00000800
+	// The "on battery" message has been sent.
+	StatusFlagOnBatteryMessage StatusFlag = 0x00020000
+	// apcupsd is polling the UPS more frequently due to a power failure.
+	StatusFlagFastPoll StatusFlag = 0x00040000
+	// Shutdown condition: battery charge is at or below BATTERYLEVEL.
+	StatusFlagShutdownLoad StatusFlag = 0x00080000
+	// Shutdown condition: time on batteries exceeds TIMEOUT.
+	StatusFlagShutdownBatteryTime StatusFlag = 0x00100000
+	// Shutdown condition: remaining runtime is at or below MINUTES.
+	StatusFlagShutdownTimeLeft StatusFlag = 0x00200000
+	// Shutdown condition: battery power has failed.
+	StatusFlagShutdownEmergency StatusFlag = 0x00400000
+	// Shutdown condition: a remote shutdown was requested.
+	StatusFlagShutdownRemote StatusFlag = 0x00800000
+	// The computer running apcupsd is plugged into the UPS.
+	StatusFlagPlugged StatusFlag = 0x01000000
+	// A battery is connected to the UPS.
+	StatusFlagBatteryPresent StatusFlag = 0x04000000
+)
+
+// statusFlagNames is the list of named StatusFlag values, in ascending bit
+// order, used for string formatting.
+var statusFlagNames = []struct {
+	f    StatusFlag
+	name string
+}{
+	{f: StatusFlagCalibration, name: "calibration"},
+	{f: StatusFlagTrim, name: "trim"},
+	{f: StatusFlagBoost, name: "boost"},
+	{f: StatusFlagOnline, name: "online"},
+	{f: StatusFlagOnBattery, name: "on battery"},
+	{f: StatusFlagOverload, name: "overload"},
+	{f: StatusFlagBatteryLow, name: "battery low"},
+	{f: StatusFlagReplaceBattery, name: "replace battery"},
+	{f: StatusFlagCommLost, name: "comm lost"},
+	{f: StatusFlagShutdown, name: "shutdown"},
+	{f: StatusFlagSlave, name: "slave"},
+	{f: StatusFlagSlaveDown, name: "slave down"},
+	{f: StatusFlagOnBatteryMessage, name: "on battery message"},
+	{f: StatusFlagFastPoll, name: "fast poll"},
+	{f: StatusFlagShutdownLoad, name: "shutdown load"},
+	{f: StatusFlagShutdownBatteryTime, name: "shutdown battery time"},
+	{f: StatusFlagShutdownTimeLeft, name: "shutdown time left"},
+	{f: StatusFlagShutdownEmergency, name: "shutdown emergency"},
+	{f: StatusFlagShutdownRemote, name: "shutdown remote"},
+	{f: StatusFlagPlugged, name: "plugged"},
+	{f: StatusFlagBatteryPresent, name: "battery present"},
+}
+
+// Has reports whether all of the bits in flag are set in f.
+func (f StatusFlag) Has(flag StatusFlag) bool { return f&flag == flag }
+
+// String returns the names of the flags set in f, separated by "|". Any bits
+// which do not correspond to a named flag are formatted in hexadecimal.
+func (f StatusFlag) String() string {
+	if f == 0 {
+		return "0"
+	}
+
+	var (
+		names   []string
+		unknown = f
+	)
+
+	for _, n := range statusFlagNames {
+		if f.Has(n.f) {
+			names = append(names, n.name)
+			unknown &^= n.f
+		}
+	}
+
+	if unknown != 0 {
+		names = append(names, fmt.Sprintf("%#x", uint32(unknown)))
+	}
+
+	return strings.Join(names, "|")
+}
+
+// parseStatusFlag parses a STATFLAG value, such as "0x05000008" or
+// "0x07000008 Status Flag", into a StatusFlag.
+func parseStatusFlag(v string) (StatusFlag, error) {
+	f, _, _ := strings.Cut(v, " ")
+
+	u, err := strconv.ParseUint(f, 0, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	return StatusFlag(u), nil
+}
@@ -0,0 +1,76 @@
+package apcupsd
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStatusFlag(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   string
+		f    StatusFlag
+		s    string
+		ok   bool
+	}{
+		{
+			desc: "invalid",
+			in:   "foo",
+		},
+		{
+			desc: "zero",
+			in:   "0x00000000",
+			s:    "0",
+			ok:   true,
+		},
+		{
+			desc: "online",
+			in:   "0x05000008",
+			f:    StatusFlagOnline | StatusFlagPlugged | StatusFlagBatteryPresent,
+			s:    "online|plugged|battery present",
+			ok:   true,
+		},
+		{
+			desc: "replace battery",
+			in:   "0x05000088 Status Flag",
+			f:    StatusFlagOnline | StatusFlagReplaceBattery | StatusFlagPlugged | StatusFlagBatteryPresent,
+			s:    "online|replace battery|plugged|battery present",
+			ok:   true,
+		},
+		{
+			desc: "unknown bits",
+			in:   "0x06000050",
+			f:    StatusFlagOnBattery | StatusFlagBatteryLow | 0x02000000 | StatusFlagBatteryPresent,
+			s:    "on battery|battery low|battery present|0x2000000",
+			ok:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			f, err := parseStatusFlag(tt.in)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to parse status flag: %v", err)
+			}
+			if !tt.ok {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+				return
+			}
+
+			if diff := cmp.Diff(tt.f, f); diff != "" {
+				t.Fatalf("unexpected StatusFlag (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tt.s, f.String()); diff != "" {
+				t.Fatalf("unexpected string (-want +got):\n%s", diff)
+			}
+
+			if tt.f != 0 && !f.Has(tt.f) {
+				t.Fatalf("expected %s to have all flags set", f)
+			}
+		})
+	}
+}
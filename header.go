@@ -0,0 +1,100 @@
+package apcupsd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Header is the APC header record which begins a status, such as
+// "001,036,0875".
+type Header struct {
+	// The STATUS format revision level.
+	Format int
+	// The number of records which follow the header, including the END APC
+	// record.
+	Records int
+	// The number of bytes in the records which follow the header, including
+	// the newline which terminates each record.
+	Bytes int
+}
+
+// String returns the Header in the same format as the APC record.
+func (h Header) String() string {
+	return fmt.Sprintf("%03d,%03d,%04d", h.Format, h.Records, h.Bytes)
+}
+
+// parseHeader parses the value of an APC header record.
+func parseHeader(v string) (Header, error) {
+	var ns [3]int
+	for i := range ns {
+		s, rest, ok := strings.Cut(v, ",")
+		if ok == (i == len(ns)-1) {
+			// Too few or too many fields.
+			return Header{}, ErrInvalidHeader
+		}
+
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || n < 0 {
+			return Header{}, ErrInvalidHeader
+		}
+
+		ns[i] = n
+		v = rest
+	}
+
+	return Header{
+		Format:  ns[0],
+		Records: ns[1],
+		Bytes:   ns[2],
+	}, nil
+}
+
+// A headerCounter counts the records and bytes which follow an APC header
+// record, to verify that a status is complete.
+type headerCounter struct {
+	h       Header
+	ok      bool
+	records int
+	bytes   int
+	end     bool
+}
+
+// start begins counting the records which follow header h.
+func (hc *headerCounter) start(h Header) {
+	*hc = headerCounter{h: h, ok: true}
+}
+
+// count counts a single record with key k, which is empty if the record
+// could not be split into a key and value.
+func (hc *headerCounter) count(k key, record string) {
+	// Anything following END APC, such as trailing blank lines in a status
+	// file, is not covered by the header.
+	if !hc.ok || hc.end {
+		return
+	}
+
+	// Records from a NIS end with a newline, but lines from a status file do
+	// not, so count the newline in either case. apcupsd counts a single byte
+	// for each newline, so a carriage return from a CRLF file is not counted.
+	hc.records++
+	hc.bytes += len(strings.TrimSuffix(strings.TrimSuffix(record, "\n"), "\r")) + 1
+	if k == keyEndAPC {
+		hc.end = true
+	}
+}
+
+// check verifies that the counted records match the header, if one was
+// present.
+func (hc *headerCounter) check() *IncompleteStatusError {
+	if !hc.ok || (hc.end && hc.records == hc.h.Records && hc.bytes == hc.h.Bytes) {
+		return nil
+	}
+
+	return &IncompleteStatusError{
+		Header:  hc.h,
+		Records: hc.records,
+		Bytes:   hc.bytes,
+		EndAPC:  hc.end,
+	}
+}
@@ -0,0 +1,263 @@
+package apcupsd
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_parseHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		v    string
+		h    Header
+		ok   bool
+	}{
+		{
+			name: "OK",
+			v:    "001,036,0875",
+			h:    Header{Format: 1, Records: 36, Bytes: 875},
+			ok:   true,
+		},
+		{
+			name: "spaces",
+			v:    "1, 5, 140",
+			h:    Header{Format: 1, Records: 5, Bytes: 140},
+			ok:   true,
+		},
+		{
+			name: "empty",
+		},
+		{
+			name: "too few fields",
+			v:    "001,036",
+		},
+		{
+			name: "too many fields",
+			v:    "001,036,0875,1",
+		},
+		{
+			name: "not a number",
+			v:    "001,foo,0875",
+		},
+		{
+			name: "negative",
+			v:    "001,-36,0875",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := parseHeader(tt.v)
+			if !tt.ok {
+				if !errors.Is(err, ErrInvalidHeader) {
+					t.Fatalf("expected invalid header, but got: %v", err)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse header: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.h, h); diff != "" {
+				t.Fatalf("unexpected Header (-want +got):\n%s", diff)
+			}
+
+			// The Header must round trip through its string form.
+			rt, err := parseHeader(h.String())
+			if err != nil {
+				t.Fatalf("failed to parse header string: %v", err)
+			}
+
+			if diff := cmp.Diff(h, rt); diff != "" {
+				t.Fatalf("unexpected round trip Header (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseStatusHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		err  *IncompleteStatusError
+		eof  bool
+	}{
+		{
+			name: "OK",
+			in: `APC      : 001,003,0079
+HOSTNAME : example
+LINEV    : 120.0 Volts
+END APC  : 2016-09-06 22:13:49 -0400
+`,
+		},
+		{
+			name: "blank lines",
+			in: `APC      : 001,004,0080
+HOSTNAME : example
+
+LINEV    : 120.0 Volts
+END APC  : 2016-09-06 22:13:49 -0400
+
+`,
+		},
+		{
+			name: "CRLF",
+			in:   "APC      : 001,003,0079\r\nHOSTNAME : example\r\nLINEV    : 120.0 Volts\r\nEND APC  : 2016-09-06 22:13:49 -0400\r\n",
+		},
+		{
+			name: "no header",
+			in: `HOSTNAME : example
+LINEV    : 120.0 Volts
+`,
+		},
+		{
+			name: "truncated",
+			in: `APC      : 001,003,0079
+HOSTNAME : example
+LINEV    : 120.0 Volts
+`,
+			err: &IncompleteStatusError{
+				Header:  Header{Format: 1, Records: 3, Bytes: 79},
+				Records: 2,
+				Bytes:   42,
+			},
+			eof: true,
+		},
+		{
+			name: "missing END APC",
+			in: `APC      : 001,003,0079
+HOSTNAME : example
+LINEV    : 120.0 Volts
+MODEL    : Back-UPS XS 1300G
+`,
+			err: &IncompleteStatusError{
+				Header:  Header{Format: 1, Records: 3, Bytes: 79},
+				Records: 3,
+				Bytes:   71,
+			},
+			eof: true,
+		},
+		{
+			name: "byte count mismatch",
+			in: `APC      : 001,003,0079
+HOSTNAME : example.com
+LINEV    : 120.0 Volts
+END APC  : 2016-09-06 22:13:49 -0400
+`,
+			err: &IncompleteStatusError{
+				Header:  Header{Format: 1, Records: 3, Bytes: 79},
+				Records: 3,
+				Bytes:   83,
+				EndAPC:  true,
+			},
+		},
+		{
+			name: "stale header",
+			in: `APC      : 001,002,0050
+HOSTNAME : example
+LINEV    : 120.0 Volts
+END APC  : 2016-09-06 22:13:49 -0400
+`,
+			err: &IncompleteStatusError{
+				Header:  Header{Format: 1, Records: 2, Bytes: 50},
+				Records: 3,
+				Bytes:   79,
+				EndAPC:  true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseStatus(strings.NewReader(tt.in))
+			if tt.err == nil {
+				if err != nil {
+					t.Fatalf("failed to parse status: %v", err)
+				}
+
+				return
+			}
+
+			var ierr *IncompleteStatusError
+			if !errors.As(err, &ierr) {
+				t.Fatalf("expected IncompleteStatusError, but got: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.err, ierr); diff != "" {
+				t.Fatalf("unexpected IncompleteStatusError (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tt.eof, errors.Is(err, io.ErrUnexpectedEOF)); diff != "" {
+				t.Fatalf("unexpected EOF (-want +got):\n%s", diff)
+			}
+
+			// Only a truncated status is discarded.
+			if diff := cmp.Diff(tt.eof, s == nil); diff != "" {
+				t.Fatalf("unexpected nil Status (-want +got):\n%s", diff)
+			}
+			if s != nil && !strings.HasPrefix(s.Hostname, "example") {
+				t.Fatalf("unexpected hostname: %q", s.Hostname)
+			}
+		})
+	}
+}
+
+func TestParseStatusHeaderParseErrors(t *testing.T) {
+	const in = `APC      : 001,003,0079
+HOSTNAME : example
+NUMXFERS : many
+`
+
+	p := newStatusParser(new(Status), ParseLenient)
+	for _, kv := range strings.SplitAfter(in, "\n") {
+		if err := p.parse(kv); err != nil {
+			t.Fatalf("failed to parse: %v", err)
+		}
+	}
+
+	s, err := p.result()
+	if s == nil || s.Hostname != "example" {
+		t.Fatalf("expected partial status, but got: %#v", s)
+	}
+
+	// Both errors must be available from the combined error.
+	var (
+		errs ParseErrors
+		ierr *IncompleteStatusError
+	)
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Key != "NUMXFERS" {
+		t.Fatalf("expected NUMXFERS parse error, but got: %v", err)
+	}
+	if !errors.As(err, &ierr) || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected truncated status, but got: %v", err)
+	}
+}
+
+func TestClientStatusIncomplete(t *testing.T) {
+	c := testPipeClient(t, []string{
+		"APC      : 001,003,0079\n",
+		"HOSTNAME : example\n",
+		"LINEV    : 120.0 Volts\n",
+	}, true)
+	c.ParseMode = ParseLenient
+
+	s, err := c.Status()
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected unexpected EOF, but got: %v", err)
+	}
+
+	var ierr *IncompleteStatusError
+	if !errors.As(err, &ierr) {
+		t.Fatalf("expected IncompleteStatusError, but got: %v", err)
+	}
+
+	// Lenient parsing returns what was received.
+	if diff := cmp.Diff("example", s.Hostname); diff != "" {
+		t.Fatalf("unexpected hostname (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,347 @@
+package apcupsd
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+)
+
+var (
+	_ json.Marshaler   = &Status{}
+	_ json.Unmarshaler = &Status{}
+)
+
+// statusJSON is the JSON representation of a Status. Pointer fields are null
+// when the corresponding value was not reported by the NIS.
+type statusJSON struct {
+	APC                            *string    `json:"apc"`
+	Date                           *time.Time `json:"date"`
+	Hostname                       *string    `json:"hostname"`
+	Version                        *string    `json:"version"`
+	UPSName                        *string    `json:"ups_name"`
+	Cable                          *string    `json:"cable"`
+	Driver                         *string    `json:"driver"`
+	UPSMode                        *string    `json:"ups_mode"`
+	StartTime                      *time.Time `json:"start_time"`
+	MasterUpdate                   *time.Time `json:"master_update"`
+	Master                         *string    `json:"master"`
+	Share                          *string    `json:"share"`
+	Model                          *string    `json:"model"`
+	Status                         *string    `json:"status"`
+	LineFail                       *string    `json:"line_fail"`
+	BatteryStatus                  *string    `json:"battery_status"`
+	LineVoltage                    *float64   `json:"line_voltage"`
+	LoadPercent                    *float64   `json:"load_percent"`
+	LoadApparentPercent            *float64   `json:"load_apparent_percent"`
+	BatteryChargePercent           *float64   `json:"battery_charge_percent"`
+	TimeLeftSeconds                *float64   `json:"time_left_seconds"`
+	MinimumBatteryChargePercent    *float64   `json:"minimum_battery_charge_percent"`
+	MinimumTimeLeftSeconds         *float64   `json:"minimum_time_left_seconds"`
+	MaximumTimeSeconds             *float64   `json:"maximum_time_seconds"`
+	MaximumLineVoltage             *float64   `json:"maximum_line_voltage"`
+	MinimumLineVoltage             *float64   `json:"minimum_line_voltage"`
+	Sense                          *string    `json:"sense"`
+	WakeDelaySeconds               *float64   `json:"wake_delay_seconds"`
+	ShutdownDelaySeconds           *float64   `json:"shutdown_delay_seconds"`
+	LowBatteryDelaySeconds         *float64   `json:"low_battery_delay_seconds"`
+	LowTransferVoltage             *float64   `json:"low_transfer_voltage"`
+	HighTransferVoltage            *float64   `json:"high_transfer_voltage"`
+	ReturnChargePercent            *float64   `json:"return_charge_percent"`
+	AlarmDelSeconds                *float64   `json:"alarm_delay_seconds"`
+	BatteryVoltage                 *float64   `json:"battery_voltage"`
+	LastTransfer                   *string    `json:"last_transfer"`
+	NumberTransfers                *int       `json:"number_transfers"`
+	XOnBattery                     *time.Time `json:"x_on_battery"`
+	TimeOnBatterySeconds           *float64   `json:"time_on_battery_seconds"`
+	CumulativeTimeOnBatterySeconds *float64   `json:"cumulative_time_on_battery_seconds"`
+	XOffBattery                    *time.Time `json:"x_off_battery"`
+	LastSelftest                   *time.Time `json:"last_selftest"`
+	Selftest                       *string    `json:"selftest"`
+	SelftestInterval               *string    `json:"selftest_interval"`
+	StatusFlags                    *uint32    `json:"status_flags"`
+	DipSwitch                      *uint8     `json:"dip_switch"`
+	Register1                      *uint8     `json:"register1"`
+	Register2                      *uint8     `json:"register2"`
+	Register3                      *uint8     `json:"register3"`
+	ManufactureDate                *string    `json:"manufacture_date"`
+	SerialNumber                   *string    `json:"serial_number"`
+	BatteryDate                    *string    `json:"battery_date"`
+	NominalOutputVoltage           *float64   `json:"nominal_output_voltage"`
+	NominalInputVoltage            *float64   `json:"nominal_input_voltage"`
+	NominalBatteryVoltage          *float64   `json:"nominal_battery_voltage"`
+	NominalPower                   *int       `json:"nominal_power"`
+	NominalApparentPower           *int       `json:"nominal_apparent_power"`
+	Humidity                       *float64   `json:"humidity"`
+	AmbientTemp                    *float64   `json:"ambient_temp"`
+	ExternalBatteries              *int       `json:"external_batteries"`
+	BadBatteries                   *int       `json:"bad_batteries"`
+	Firmware                       *string    `json:"firmware"`
+	APCModel                       *string    `json:"apc_model"`
+	EndAPC                         *time.Time `json:"end_apc"`
+	InternalTemp                   *float64   `json:"internal_temp"`
+	OutputVoltage                  *float64   `json:"output_voltage"`
+	LineFrequency                  *float64   `json:"line_frequency"`
+	OutputAmps                     *float64   `json:"output_amps"`
+
+	Raw []KeyValue `json:"raw"`
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// Fields are encoded using snake_case names, and durations are encoded as a
+// number of seconds with a "_seconds" suffix on the field name. Times are
+// encoded in RFC 3339 format, except for dates such as battery_date which are
+// encoded in "2006-01-02" format. A field is encoded as null if it holds a zero
+// value and was not reported according to Has, such as a time reported as
+// "N/A" or a value which the UPS does not report. The raw key/value pairs are
+// encoded as a list of objects in the "raw" field.
+//
+// MarshalJSON has a value receiver so that a Status is encoded the same way
+// whether or not it is referenced by a pointer.
+func (s Status) MarshalJSON() ([]byte, error) {
+	e := jsonEncoder{s: &s}
+
+	j := statusJSON{
+		APC:                            e.str(keyAPC, s.APC),
+		Date:                           e.time(s.Date),
+		Hostname:                       e.str(keyHostname, s.Hostname),
+		Version:                        e.str(keyVersion, s.Version),
+		UPSName:                        e.str(keyUPSName, s.UPSName),
+		Cable:                          e.str(keyCable, s.Cable),
+		Driver:                         e.str(keyDriver, s.Driver),
+		UPSMode:                        e.str(keyUPSMode, s.UPSMode),
+		StartTime:                      e.time(s.StartTime),
+		MasterUpdate:                   e.time(s.MasterUpdate),
+		Master:                         e.str(keyMaster, s.Master),
+		Share:                          e.str(keyShare, s.Share),
+		Model:                          e.str(keyModel, s.Model),
+		Status:                         e.str(keyStatus, s.Status),
+		LineFail:                       e.str(keyLineFail, s.LineFail),
+		BatteryStatus:                  e.str(keyBattStat, s.BatteryStatus),
+		LineVoltage:                    e.float(keyLineV, s.LineVoltage),
+		LoadPercent:                    e.float(keyLoadPct, s.LoadPercent),
+		LoadApparentPercent:            e.float(keyLoadAPnt, s.LoadApparentPercent),
+		BatteryChargePercent:           e.float(keyBCharge, s.BatteryChargePercent),
+		TimeLeftSeconds:                e.duration(keyTimeLeft, s.TimeLeft),
+		MinimumBatteryChargePercent:    e.float(keyMBattChg, s.MinimumBatteryChargePercent),
+		MinimumTimeLeftSeconds:         e.duration(keyMinTimeL, s.MinimumTimeLeft),
+		MaximumTimeSeconds:             e.duration(keyMaxTime, s.MaximumTime),
+		MaximumLineVoltage:             e.float(keyMaxLineV, s.MaximumLineVoltage),
+		MinimumLineVoltage:             e.float(keyMinLineV, s.MinimumLineVoltage),
+		Sense:                          e.str(keySense, s.Sense),
+		WakeDelaySeconds:               e.duration(keyDWake, s.WakeDelay),
+		ShutdownDelaySeconds:           e.duration(keyDShutd, s.ShutdownDelay),
+		LowBatteryDelaySeconds:         e.duration(keyDLowBatt, s.LowBatteryDelay),
+		LowTransferVoltage:             e.float(keyLoTrans, s.LowTransferVoltage),
+		HighTransferVoltage:            e.float(keyHiTrans, s.HighTransferVoltage),
+		ReturnChargePercent:            e.float(keyRetPct, s.ReturnChargePercent),
+		AlarmDelSeconds:                e.duration(keyAlarmDel, s.AlarmDel),
+		BatteryVoltage:                 e.float(keyBattV, s.BatteryVoltage),
+		LastTransfer:                   e.str(keyLastXfer, s.LastTransfer),
+		NumberTransfers:                e.int(keyNumXfers, s.NumberTransfers),
+		XOnBattery:                     e.time(s.XOnBattery),
+		TimeOnBatterySeconds:           e.duration(keyTOnBatt, s.TimeOnBattery),
+		CumulativeTimeOnBatterySeconds: e.duration(keyCumOnBatt, s.CumulativeTimeOnBattery),
+		XOffBattery:                    e.time(s.XOffBattery),
+		LastSelftest:                   e.time(s.LastSelftest),
+		Selftest:                       e.str(keySelftest, string(s.Selftest)),
+		SelftestInterval:               e.str(keyStestI, s.SelftestInterval),
+		StatusFlags:                    e.uint32(keyStatFlag, uint32(s.StatusFlags)),
+		DipSwitch:                      e.uint8(keyDipSw, s.DipSwitch),
+		Register1:                      e.uint8(keyReg1, s.Register1),
+		Register2:                      e.uint8(keyReg2, s.Register2),
+		Register3:                      e.uint8(keyReg3, s.Register3),
+		ManufactureDate:                e.date(s.ManufactureDate),
+		SerialNumber:                   e.str(keySerialNo, s.SerialNumber),
+		BatteryDate:                    e.date(s.BatteryDate),
+		NominalOutputVoltage:           e.float(keyNomOutV, s.NominalOutputVoltage),
+		NominalInputVoltage:            e.float(keyNomInV, s.NominalInputVoltage),
+		NominalBatteryVoltage:          e.float(keyNomBattV, s.NominalBatteryVoltage),
+		NominalPower:                   e.int(keyNomPower, s.NominalPower),
+		NominalApparentPower:           e.int(keyNomAPnt, s.NominalApparentPower),
+		Humidity:                       e.float(keyHumidity, s.Humidity),
+		AmbientTemp:                    e.float(keyAmbTemp, s.AmbientTemp),
+		ExternalBatteries:              e.int(keyExtBatts, s.ExternalBatteries),
+		BadBatteries:                   e.int(keyBadBatts, s.BadBatteries),
+		Firmware:                       e.str(keyFirmware, s.Firmware),
+		APCModel:                       e.str(keyAPCModel, s.APCModel),
+		EndAPC:                         e.time(s.EndAPC),
+		InternalTemp:                   e.float(keyITemp, s.InternalTemp),
+		OutputVoltage:                  e.float(keyOutV, s.OutputVoltage),
+		LineFrequency:                  e.float(keyLineFrequency, s.LineFrequency),
+		OutputAmps:                     e.float(keyOutputAmps, s.OutputAmps),
+
+		Raw: s.Raw,
+	}
+
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding the format produced by
+// MarshalJSON. Null and missing fields are decoded as zero values. Any
+// existing contents of s are replaced.
+func (s *Status) UnmarshalJSON(b []byte) error {
+	var j statusJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+
+	// The Header is derived from the APC record, which need not be valid.
+	var header Header
+	if j.APC != nil {
+		header, _ = parseHeader(*j.APC)
+	}
+
+	manDate, err := parseJSONDate(j.ManufactureDate)
+	if err != nil {
+		return err
+	}
+
+	battDate, err := parseJSONDate(j.BatteryDate)
+	if err != nil {
+		return err
+	}
+
+	*s = Status{
+		APC:                         deref(j.APC),
+		Header:                      header,
+		Date:                        deref(j.Date),
+		Hostname:                    deref(j.Hostname),
+		Version:                     deref(j.Version),
+		UPSName:                     deref(j.UPSName),
+		Cable:                       deref(j.Cable),
+		Driver:                      deref(j.Driver),
+		UPSMode:                     deref(j.UPSMode),
+		StartTime:                   deref(j.StartTime),
+		MasterUpdate:                deref(j.MasterUpdate),
+		Master:                      deref(j.Master),
+		Share:                       deref(j.Share),
+		Model:                       deref(j.Model),
+		Status:                      deref(j.Status),
+		LineFail:                    deref(j.LineFail),
+		BatteryStatus:               deref(j.BatteryStatus),
+		LineVoltage:                 deref(j.LineVoltage),
+		LoadPercent:                 deref(j.LoadPercent),
+		LoadApparentPercent:         deref(j.LoadApparentPercent),
+		BatteryChargePercent:        deref(j.BatteryChargePercent),
+		TimeLeft:                    seconds(j.TimeLeftSeconds),
+		MinimumBatteryChargePercent: deref(j.MinimumBatteryChargePercent),
+		MinimumTimeLeft:             seconds(j.MinimumTimeLeftSeconds),
+		MaximumTime:                 seconds(j.MaximumTimeSeconds),
+		MaximumLineVoltage:          deref(j.MaximumLineVoltage),
+		MinimumLineVoltage:          deref(j.MinimumLineVoltage),
+		Sense:                       deref(j.Sense),
+		WakeDelay:                   seconds(j.WakeDelaySeconds),
+		ShutdownDelay:               seconds(j.ShutdownDelaySeconds),
+		LowBatteryDelay:             seconds(j.LowBatteryDelaySeconds),
+		LowTransferVoltage:          deref(j.LowTransferVoltage),
+		HighTransferVoltage:         deref(j.HighTransferVoltage),
+		ReturnChargePercent:         deref(j.ReturnChargePercent),
+		AlarmDel:                    seconds(j.AlarmDelSeconds),
+		BatteryVoltage:              deref(j.BatteryVoltage),
+		LastTransfer:                deref(j.LastTransfer),
+		NumberTransfers:             deref(j.NumberTransfers),
+		XOnBattery:                  deref(j.XOnBattery),
+		TimeOnBattery:               seconds(j.TimeOnBatterySeconds),
+		CumulativeTimeOnBattery:     seconds(j.CumulativeTimeOnBatterySeconds),
+		XOffBattery:                 deref(j.XOffBattery),
+		LastSelftest:                deref(j.LastSelftest),
+		Selftest:                    SelftestResult(deref(j.Selftest)),
+		SelftestInterval:            deref(j.SelftestInterval),
+		StatusFlags:                 StatusFlag(deref(j.StatusFlags)),
+		DipSwitch:                   deref(j.DipSwitch),
+		Register1:                   deref(j.Register1),
+		Register2:                   deref(j.Register2),
+		Register3:                   deref(j.Register3),
+		ManufactureDate:             manDate,
+		SerialNumber:                deref(j.SerialNumber),
+		BatteryDate:                 battDate,
+		NominalOutputVoltage:        deref(j.NominalOutputVoltage),
+		NominalInputVoltage:         deref(j.NominalInputVoltage),
+		NominalBatteryVoltage:       deref(j.NominalBatteryVoltage),
+		NominalPower:                deref(j.NominalPower),
+		NominalApparentPower:        deref(j.NominalApparentPower),
+		Humidity:                    deref(j.Humidity),
+		AmbientTemp:                 deref(j.AmbientTemp),
+		ExternalBatteries:           deref(j.ExternalBatteries),
+		BadBatteries:                deref(j.BadBatteries),
+		Firmware:                    deref(j.Firmware),
+		APCModel:                    deref(j.APCModel),
+		EndAPC:                      deref(j.EndAPC),
+		InternalTemp:                deref(j.InternalTemp),
+		OutputVoltage:               deref(j.OutputVoltage),
+		LineFrequency:               deref(j.LineFrequency),
+		OutputAmps:                  deref(j.OutputAmps),
+
+		Raw: j.Raw,
+	}
+
+	return nil
+}
+
+// A jsonEncoder produces the nullable fields of a statusJSON from a Status.
+type jsonEncoder struct {
+	s *Status
+}
+
+// reported reports whether a value for k should be encoded, given whether
+// the value is its type's zero value.
+func (e jsonEncoder) reported(k key, zero bool) bool {
+	return !zero || e.s.Has(string(k))
+}
+
+func (e jsonEncoder) str(k key, v string) *string { return ptr(v, e.reported(k, v == "")) }
+
+func (e jsonEncoder) float(k key, f float64) *float64 { return ptr(f, e.reported(k, f == 0)) }
+
+func (e jsonEncoder) int(k key, i int) *int { return ptr(i, e.reported(k, i == 0)) }
+
+func (e jsonEncoder) uint8(k key, u uint8) *uint8 { return ptr(u, e.reported(k, u == 0)) }
+
+func (e jsonEncoder) uint32(k key, u uint32) *uint32 { return ptr(u, e.reported(k, u == 0)) }
+
+func (e jsonEncoder) duration(k key, d time.Duration) *float64 {
+	return ptr(d.Seconds(), e.reported(k, d == 0))
+}
+
+// time encodes a time.Time, which is null if zero because apcupsd reports
+// missing times as "N/A".
+func (e jsonEncoder) time(t time.Time) *time.Time { return ptr(t, !t.IsZero()) }
+
+// date encodes the date of a time.Time, which is null if zero.
+func (e jsonEncoder) date(t time.Time) *string { return ptr(t.Format(dateFormat), !t.IsZero()) }
+
+// ptr returns a pointer to v if ok is true, or nil otherwise.
+func ptr[T any](v T, ok bool) *T {
+	if !ok {
+		return nil
+	}
+
+	return &v
+}
+
+// deref returns the value pointed to by p, or the zero value if p is nil.
+func deref[T any](p *T) T {
+	if p == nil {
+		var v T
+		return v
+	}
+
+	return *p
+}
+
+// parseJSONDate parses a date encoded by jsonEncoder.date in the local time
+// zone, or returns zero if p is nil.
+func parseJSONDate(p *string) (time.Time, error) {
+	if p == nil {
+		return time.Time{}, nil
+	}
+
+	return time.ParseInLocation(dateFormat, *p, time.Local)
+}
+
+// seconds converts a number of seconds into a time.Duration, or zero if p is
+// nil.
+func seconds(p *float64) time.Duration {
+	return time.Duration(math.Round(deref(p) * float64(time.Second)))
+}
@@ -0,0 +1,153 @@
+package apcupsd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStatusMarshalJSON(t *testing.T) {
+	const in = `HOSTNAME : example
+LINEV    : 120.0 Volts
+LOADPCT  : 0.0 Percent
+TIMELEFT : 10.5 Minutes
+XONBATT  : N/A
+END APC  : 2016-09-06 22:13:49 -0400
+`
+
+	s, err := ParseStatus(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("failed to parse status: %v", err)
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("failed to marshal JSON: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("failed to unmarshal JSON: %v", err)
+	}
+
+	// Check a representative subset of fields.
+	want := map[string]interface{}{
+		"hostname":          "example",
+		"line_voltage":      120.0,
+		"load_percent":      0.0,
+		"time_left_seconds": 630.0,
+		"x_on_battery":      nil,
+		"end_apc":           "2016-09-06T22:13:49-04:00",
+		"output_voltage":    nil,
+		"model":             nil,
+		"status_flags":      nil,
+	}
+
+	for k, v := range want {
+		gv, ok := got[k]
+		if !ok {
+			t.Fatalf("missing JSON field %q", k)
+		}
+
+		if diff := cmp.Diff(v, gv); diff != "" {
+			t.Fatalf("unexpected JSON field %q (-want +got):\n%s", k, diff)
+		}
+	}
+
+	raw := []interface{}{
+		map[string]interface{}{"key": "HOSTNAME", "value": "example"},
+		map[string]interface{}{"key": "LINEV", "value": "120.0 Volts"},
+		map[string]interface{}{"key": "LOADPCT", "value": "0.0 Percent"},
+		map[string]interface{}{"key": "TIMELEFT", "value": "10.5 Minutes"},
+		map[string]interface{}{"key": "XONBATT", "value": "N/A"},
+		map[string]interface{}{"key": "END APC", "value": "2016-09-06 22:13:49 -0400"},
+	}
+
+	if diff := cmp.Diff(raw, got["raw"]); diff != "" {
+		t.Fatalf("unexpected raw JSON (-want +got):\n%s", diff)
+	}
+}
+
+func TestStatusMarshalJSONValue(t *testing.T) {
+	s, err := ParseStatus(strings.NewReader(dumpUSB))
+	if err != nil {
+		t.Fatalf("failed to parse status: %v", err)
+	}
+
+	want, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("failed to marshal JSON: %v", err)
+	}
+
+	// Values, including those held in containers, must use the same encoding
+	// as pointers.
+	for _, v := range []interface{}{*s, []Status{*s}, map[string]Status{"ups": *s}} {
+		got, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("failed to marshal JSON: %v", err)
+		}
+
+		if !strings.Contains(string(got), string(want)) {
+			t.Fatalf("unexpected JSON for %T:\n%s", v, got)
+		}
+	}
+}
+
+func TestStatusUnmarshalJSON(t *testing.T) {
+	const in = `{
+	"hostname": "example",
+	"line_voltage": 120.5,
+	"time_left_seconds": 90.5,
+	"date": "2020-04-27T10:00:00Z",
+	"x_on_battery": null,
+	"status_flags": 8,
+	"selftest": "OK",
+	"nominal_power": 865
+}`
+
+	want := &Status{
+		Hostname:     "example",
+		LineVoltage:  120.5,
+		TimeLeft:     90*time.Second + 500*time.Millisecond,
+		Date:         time.Date(2020, time.April, 27, 10, 0, 0, 0, time.UTC),
+		StatusFlags:  StatusFlagOnline,
+		Selftest:     SelftestOK,
+		NominalPower: 865,
+	}
+
+	// Existing contents must be replaced.
+	got := &Status{Model: "stale"}
+	if err := json.Unmarshal([]byte(in), got); err != nil {
+		t.Fatalf("failed to unmarshal JSON: %v", err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected Status (-want +got):\n%s", diff)
+	}
+}
+
+func TestStatusJSONRoundTrip(t *testing.T) {
+	for _, dump := range []string{dumpUSB, dumpSNMP, dumpPCNET, dumpModbus, dumpNet} {
+		want, err := ParseStatus(strings.NewReader(dump))
+		if err != nil {
+			t.Fatalf("failed to parse status: %v", err)
+		}
+
+		b, err := json.Marshal(want)
+		if err != nil {
+			t.Fatalf("failed to marshal JSON: %v", err)
+		}
+
+		var got Status
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatalf("failed to unmarshal JSON: %v", err)
+		}
+
+		if diff := cmp.Diff(want, &got); diff != "" {
+			t.Fatalf("unexpected round trip Status (-want +got):\n%s", diff)
+		}
+	}
+}
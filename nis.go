@@ -2,79 +2,120 @@ package apcupsd
 
 import (
 	"encoding/binary"
-	"errors"
 	"io"
 	"math"
 	"sync"
 )
 
-var _ io.ReadWriteCloser = &nisReadWriteCloser{}
+// A Conn is a connection which reads and writes records using the NIS
+// protocol, in which each record is prefixed with its 2 byte big endian
+// length. A NIS responds to each command with a sequence of records
+// terminated by an empty record.
+//
+// Conn can be used to send commands which are not supported by Client, or
+// to implement a NIS. A Conn is safe for concurrent use, but concurrent
+// commands will interleave their records.
+type Conn struct {
+	rmu  sync.Mutex
+	rwc  io.ReadWriteCloser
+	lenb []byte
 
-// newNISReadWriteCloser wraps an io.ReadWriteCloser.
-func newNISReadWriteCloser(rwc io.ReadWriteCloser) *nisReadWriteCloser {
-	return &nisReadWriteCloser{
+	wmu sync.Mutex
+	wb  []byte
+}
+
+// NewConn creates a Conn which reads and writes records using rwc. Conn's
+// Close method will close rwc when called.
+func NewConn(rwc io.ReadWriteCloser) *Conn {
+	return &Conn{
 		rwc:  rwc,
 		lenb: make([]byte, 2),
 	}
 }
 
-// An nisReadWriteCloser wraps an io.ReadWriteCloser with one that can encode
-// and decode messages using the NIS's protocol.
-type nisReadWriteCloser struct {
-	mu   sync.Mutex
-	rwc  io.ReadWriteCloser
-	lenb []byte
-}
-
-// Read reads messages from the NIS using its protocol:
-//  - 2 bytes: length of next message
-//  - N bytes: data
-func (rwc *nisReadWriteCloser) Read(b []byte) (int, error) {
-	rwc.mu.Lock()
-	defer rwc.mu.Unlock()
+// ReadRecord reads the next record into b, returning the number of bytes
+// read. If the record is empty, indicating the end of a response, io.EOF is
+// returned. If the connection ends before the next record, io.ErrUnexpectedEOF
+// is returned.
+//
+// The length of b is the maximum record size. If the next record is larger
+// than b, or is truncated or malformed, a ProtocolError is returned and the
+// Conn can no longer be used.
+func (c *Conn) ReadRecord(b []byte) (int, error) {
+	c.rmu.Lock()
+	defer c.rmu.Unlock()
 
 	// Read two byte length of next data.
-	if _, err := io.ReadFull(rwc.rwc, rwc.lenb); err != nil {
-		return 0, err
+	if _, err := io.ReadFull(c.rwc, c.lenb); err != nil {
+		switch err {
+		case io.EOF:
+			// Every response ends with an empty record, so the connection
+			// ending before one is unexpected.
+			return 0, io.ErrUnexpectedEOF
+		case io.ErrUnexpectedEOF:
+			return 0, &ProtocolError{Err: ErrRecordTruncated}
+		default:
+			return 0, err
+		}
 	}
 
 	// When no more data returned from server, return io.EOF.
-	length := binary.BigEndian.Uint16(rwc.lenb)
+	length := int(binary.BigEndian.Uint16(c.lenb))
 	if length == 0 {
 		return 0, io.EOF
 	}
 
-	return io.ReadFull(rwc.rwc, b[:length])
-}
+	// Never trust the length sent by the peer.
+	if length > len(b) {
+		return 0, &ProtocolError{Length: length, Err: ErrRecordTooLarge}
+	}
+
+	n, err := io.ReadFull(c.rwc, b[:length])
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return n, &ProtocolError{Length: length, Err: ErrRecordTruncated}
+		}
 
-// errBufferTooLarge indicates that nisReadWriteCloser.Write was passed a
-// buffer that is too large to send to the NIS.
-var errBufferTooLarge = errors.New("apcupsd: buffer too large; must be size of uint16 or less")
+		return n, err
+	}
+
+	if !isText(b[:n]) {
+		return n, &ProtocolError{Length: length, Err: ErrRecordMalformed}
+	}
 
-// Write writes messages to the NIS using its protocol by prepending each
-// message with its 2 byte length.
-func (rwc *nisReadWriteCloser) Write(b []byte) (int, error) {
+	return n, nil
+}
+
+// WriteRecord writes b as a single record. An empty b writes the empty record
+// which indicates the end of a response.
+func (c *Conn) WriteRecord(b []byte) error {
 	// Cannot write more than math.MaxUint16 bytes.
 	if len(b) > math.MaxUint16 {
-		return 0, errBufferTooLarge
+		return ErrBufferTooLarge
 	}
 
-	rwc.mu.Lock()
-	defer rwc.mu.Unlock()
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
 
-	// Two byte length of data
-	binary.BigEndian.PutUint16(rwc.lenb, uint16(len(b)))
+	// Send the two byte length of the data and the data in a single write.
+	c.wb = binary.BigEndian.AppendUint16(c.wb[:0], uint16(len(b)))
+	c.wb = append(c.wb, b...)
 
-	// Send data and indicate the length of the body to caller
-	n, err := rwc.rwc.Write(append(rwc.lenb, b...))
-	n -= len(rwc.lenb)
-	return n, err
+	_, err := c.rwc.Write(c.wb)
+	return err
 }
 
 // Close closes the underlying io.ReadWriteCloser.
-func (rwc *nisReadWriteCloser) Close() error {
-	rwc.mu.Lock()
-	defer rwc.mu.Unlock()
+func (c *Conn) Close() error { return c.rwc.Close() }
+
+// isText reports whether b contains only text, meaning no control characters
+// other than whitespace.
+func isText(b []byte) bool {
+	for _, c := range b {
+		if (c < 0x20 && c != '\t' && c != '\n' && c != '\r') || c == 0x7f {
+			return false
+		}
+	}
 
-	return rwc.rwc.Close()
+	return true
 }
@@ -1,26 +1,30 @@
 package apcupsd
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 	"math"
+	"net"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
-func Test_nisReadWriteCloserRead(t *testing.T) {
+func TestConnReadRecord(t *testing.T) {
 	in := []byte("HELLO : WORLD")
 	out := make([]byte, 16)
 
-	rwc := testRWC(in, nil)
+	c := testConn(in, nil)
 
 	for {
 		// First write returns "key : value" data, second should
 		// return EOF
-		n, err := rwc.Read(out)
+		n, err := c.ReadRecord(out)
 		if err == io.EOF {
 			break
 		}
@@ -34,36 +38,206 @@ func Test_nisReadWriteCloserRead(t *testing.T) {
 	}
 }
 
-func Test_nisReadWriteCloserWriteBufferTooLarge(t *testing.T) {
-	rwc := testRWC(nil, nil)
-	_, err := rwc.Write(make([]byte, math.MaxUint16+1))
-	if !errors.Is(err, errBufferTooLarge) {
+func TestConnReadRecordAdversarial(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   []byte
+		recs []string
+		err  error
+		perr *ProtocolError
+	}{
+		{
+			desc: "OK",
+			in:   frames("HELLO : WORLD\n", "FOO : bar\n", ""),
+			recs: []string{"HELLO : WORLD\n", "FOO : bar\n"},
+			err:  io.EOF,
+		},
+		{
+			desc: "OK maximum size",
+			in:   frames(strings.Repeat("a", 16), ""),
+			recs: []string{strings.Repeat("a", 16)},
+			err:  io.EOF,
+		},
+		{
+			desc: "empty",
+			err:  io.ErrUnexpectedEOF,
+		},
+		{
+			desc: "no end of response",
+			in:   frames("HELLO : WORLD\n"),
+			recs: []string{"HELLO : WORLD\n"},
+			err:  io.ErrUnexpectedEOF,
+		},
+		{
+			desc: "too large",
+			in:   frames(strings.Repeat("a", 17)),
+			perr: &ProtocolError{Length: 17, Err: ErrRecordTooLarge},
+		},
+		{
+			desc: "maximum length prefix",
+			in:   []byte{0xff, 0xff, 'a'},
+			perr: &ProtocolError{Length: math.MaxUint16, Err: ErrRecordTooLarge},
+		},
+		{
+			desc: "HTTP response",
+			in:   []byte("HTTP/1.1 400 Bad Request\r\n\r\n"),
+			perr: &ProtocolError{Length: 0x4854, Err: ErrRecordTooLarge},
+		},
+		{
+			desc: "truncated length",
+			in:   []byte{0x00},
+			perr: &ProtocolError{Err: ErrRecordTruncated},
+		},
+		{
+			desc: "truncated after length",
+			in:   []byte{0x00, 0x04},
+			perr: &ProtocolError{Length: 4, Err: ErrRecordTruncated},
+		},
+		{
+			desc: "truncated record",
+			in:   []byte{0x00, 0x04, 'a', 'b'},
+			perr: &ProtocolError{Length: 4, Err: ErrRecordTruncated},
+		},
+		{
+			desc: "truncated after record",
+			in:   append(frames("HELLO : WORLD\n"), 0x00),
+			recs: []string{"HELLO : WORLD\n"},
+			perr: &ProtocolError{Err: ErrRecordTruncated},
+		},
+		{
+			desc: "NUL byte",
+			in:   frames("HELLO\x00WORLD"),
+			perr: &ProtocolError{Length: 11, Err: ErrRecordMalformed},
+		},
+		{
+			desc: "binary",
+			in:   frames("\x16\x03\x01\x02"),
+			perr: &ProtocolError{Length: 4, Err: ErrRecordMalformed},
+		},
+		{
+			desc: "malformed after record",
+			in:   frames("HELLO : WORLD\n", "\x7f"),
+			recs: []string{"HELLO : WORLD\n"},
+			perr: &ProtocolError{Length: 1, Err: ErrRecordMalformed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			c := NewConn(&bytesRWC{r: bytes.NewReader(tt.in)})
+
+			recs, err := readAll(c, make([]byte, 16))
+
+			if diff := cmp.Diff(tt.recs, recs); diff != "" {
+				t.Fatalf("unexpected records (-want +got):\n%s", diff)
+			}
+
+			if tt.perr == nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected error %v, but got: %v", tt.err, err)
+				}
+
+				return
+			}
+
+			var perr *ProtocolError
+			if !errors.As(err, &perr) {
+				t.Fatalf("expected ProtocolError, but got: %v", err)
+			}
+
+			if diff := cmp.Diff(*tt.perr, *perr, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("unexpected ProtocolError (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func FuzzConnReadRecord(f *testing.F) {
+	f.Add(frames("HELLO : WORLD\n", ""))
+	f.Add([]byte{0xff, 0xff})
+	f.Add([]byte{0x00, 0x04, 'a'})
+	f.Add(frames("HELLO\x00WORLD"))
+
+	f.Fuzz(func(t *testing.T, in []byte) {
+		c := NewConn(&bytesRWC{r: bytes.NewReader(in)})
+
+		// Reading must never panic, must terminate, and must only return
+		// records which fit in the buffer.
+		recs, err := readAll(c, make([]byte, maxString))
+		if err == nil {
+			t.Fatal("expected an error, but none occurred")
+		}
+
+		for _, r := range recs {
+			if len(r) > maxString {
+				t.Fatalf("record too large: %d", len(r))
+			}
+		}
+	})
+}
+
+func TestConnWriteRecordBufferTooLarge(t *testing.T) {
+	c := testConn(nil, nil)
+	err := c.WriteRecord(make([]byte, math.MaxUint16+1))
+	if !errors.Is(err, ErrBufferTooLarge) {
 		t.Fatalf("expected buffer too large, but got: %v", err)
 	}
 }
 
-func Test_nisReadWriteCloserWrite(t *testing.T) {
+func TestConnWriteRecord(t *testing.T) {
 	in := []byte("HELLO : WORLD")
 	out := make([]byte, 16)
 
-	rwc := testRWC(nil, out)
+	c := testConn(nil, out)
 
-	n, err := rwc.Write(in)
-	if err != nil {
+	if err := c.WriteRecord(in); err != nil {
 		t.Fatalf("failed to write: %v", err)
 	}
 
-	// Write prepends length; two bytes.
-	n += 2
+	// WriteRecord prepends length; two bytes.
 	in = append([]byte{0, byte(len(in))}, in...)
 
-	if diff := cmp.Diff(in, out[:n]); diff != "" {
+	if diff := cmp.Diff(in, out[:len(in)]); diff != "" {
 		t.Fatalf("unexpected byte output (-want +got):\n%s", diff)
 	}
 }
 
-func testRWC(rb []byte, wb []byte) io.ReadWriteCloser {
-	return newNISReadWriteCloser(&testReadWriterCloser{
+// frames encodes each string as a NIS record.
+func frames(recs ...string) []byte {
+	var b []byte
+	for _, r := range recs {
+		lenb, rb := kvBytes(r)
+		b = append(b, lenb...)
+		b = append(b, rb...)
+	}
+
+	return b
+}
+
+// readAll reads records from c into b until an error occurs.
+func readAll(c *Conn, b []byte) ([]string, error) {
+	var recs []string
+	for {
+		n, err := c.ReadRecord(b)
+		if err != nil {
+			return recs, err
+		}
+
+		recs = append(recs, string(b[:n]))
+	}
+}
+
+// A bytesRWC is an io.ReadWriteCloser which reads from r and discards writes.
+type bytesRWC struct {
+	r io.Reader
+}
+
+func (rwc *bytesRWC) Read(b []byte) (int, error)  { return rwc.r.Read(b) }
+func (rwc *bytesRWC) Write(b []byte) (int, error) { return len(b), nil }
+func (rwc *bytesRWC) Close() error                { return nil }
+
+func testConn(rb []byte, wb []byte) *Conn {
+	return NewConn(&testReadWriterCloser{
 		rb: rb,
 		wb: wb,
 	})
@@ -91,7 +265,7 @@ func (rwc *testReadWriterCloser) Read(b []byte) (int, error) {
 		n := copy(b, rwc.rb)
 		return n, nil
 	case 2:
-		// Signal EOF to nisReadWriteCloser
+		// Signal EOF to Conn
 		binary.BigEndian.PutUint16(b[0:2], 0)
 		return 2, nil
 	default:
@@ -105,3 +279,36 @@ func (rwc *testReadWriterCloser) Write(b []byte) (int, error) {
 }
 
 func (rwc *testReadWriterCloser) Close() error { return nil }
+
+func TestConnRoundTrip(t *testing.T) {
+	c1, c2 := net.Pipe()
+	client, server := NewConn(c1), NewConn(c2)
+	defer client.Close()
+	defer server.Close()
+
+	recs := []string{"HELLO : WORLD\n", "FOO : bar\n"}
+
+	errC := make(chan error, 1)
+	go func() {
+		for _, r := range recs {
+			if err := server.WriteRecord([]byte(r)); err != nil {
+				errC <- err
+				return
+			}
+		}
+
+		errC <- server.WriteRecord(nil)
+	}()
+
+	got, err := readAll(client, make([]byte, maxString))
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("expected EOF, but got: %v", err)
+	}
+	if err := <-errC; err != nil {
+		t.Fatalf("failed to write records: %v", err)
+	}
+
+	if diff := cmp.Diff(recs, got); diff != "" {
+		t.Fatalf("unexpected records (-want +got):\n%s", diff)
+	}
+}
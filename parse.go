@@ -0,0 +1,108 @@
+package apcupsd
+
+import (
+	"errors"
+	"strings"
+)
+
+// A ParseMode specifies how malformed and unknown key/value pairs are handled
+// while parsing a Status.
+type ParseMode int
+
+// Possible ParseMode values.
+const (
+	// ParseFailFast stops parsing at the first malformed key/value pair and
+	// returns its ParseError. Unknown keys are ignored. This is the default.
+	ParseFailFast ParseMode = iota
+
+	// ParseLenient parses every key/value pair, collecting any errors into
+	// ParseErrors. Unknown keys are ignored. The partially populated Status
+	// is returned along with the error.
+	ParseLenient
+
+	// ParseStrict behaves like ParseLenient, but also reports a ParseError
+	// wrapping ErrUnknownKey for each unknown key.
+	ParseStrict
+)
+
+// A statusParser parses key/value pairs into a Status according to a
+// ParseMode.
+type statusParser struct {
+	s    *Status
+	mode ParseMode
+	errs ParseErrors
+	hc   headerCounter
+}
+
+// newStatusParser creates a statusParser which populates s.
+func newStatusParser(s *Status, mode ParseMode) statusParser {
+	return statusParser{
+		s:    s,
+		mode: mode,
+	}
+}
+
+// parse parses a single key/value pair. It returns an error only when parsing
+// must stop immediately.
+func (p *statusParser) parse(kv string) error {
+	// Blank records carry no data, but are still covered by the header.
+	if strings.TrimSpace(kv) == "" {
+		p.hc.count("", kv)
+		return nil
+	}
+
+	k, v, err := splitKV(kv)
+	if err != nil {
+		p.hc.count("", kv)
+		return p.fail(&ParseError{Value: kv, Err: err})
+	}
+
+	match, err := p.s.setKV(k, v)
+	if k == keyAPC && err == nil {
+		p.hc.start(p.s.Header)
+	} else {
+		p.hc.count(k, kv)
+	}
+
+	if err == nil && !match && p.mode == ParseStrict {
+		err = ErrUnknownKey
+	}
+	if err != nil {
+		return p.fail(&ParseError{Key: string(k), Value: v, Err: err})
+	}
+
+	return nil
+}
+
+// fail handles a ParseError according to the parser's mode.
+func (p *statusParser) fail(err *ParseError) error {
+	if p.mode == ParseFailFast {
+		return err
+	}
+
+	p.errs = append(p.errs, err)
+	return nil
+}
+
+// result returns the parsed Status and any errors collected while parsing,
+// including an IncompleteStatusError if the Status does not match its APC
+// header. A truncated Status is only returned in the lenient and strict
+// modes, but a complete Status whose header is inaccurate is always returned.
+// If there are both ParseErrors and an IncompleteStatusError, the error wraps
+// both.
+func (p *statusParser) result() (*Status, error) {
+	herr := p.hc.check()
+
+	switch {
+	case herr != nil && herr.truncated() && p.mode == ParseFailFast:
+		return nil, herr
+	case herr != nil && len(p.errs) > 0:
+		return p.s, errors.Join(p.errs, herr)
+	case herr != nil:
+		return p.s, herr
+	case len(p.errs) > 0:
+		return p.s, p.errs
+	default:
+		return p.s, nil
+	}
+}
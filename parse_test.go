@@ -0,0 +1,119 @@
+package apcupsd
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func Test_statusParser(t *testing.T) {
+	numErr := &strconv.NumError{Func: "ParseFloat", Num: "foo", Err: strconv.ErrSyntax}
+
+	kvs := []string{
+		"HOSTNAME : example",
+		"LINEV    : foo Volts",
+		"garbage",
+		"FOO      : bar",
+		"LOADPCT  : 13.0 Percent",
+		"TIMELEFT : 1",
+	}
+
+	tests := []struct {
+		desc string
+		mode ParseMode
+		s    *Status
+		errs []*ParseError
+		err  error
+	}{
+		{
+			desc: "fail fast",
+			mode: ParseFailFast,
+			err:  &ParseError{Key: "LINEV", Value: "foo Volts", Err: numErr},
+		},
+		{
+			desc: "lenient",
+			mode: ParseLenient,
+			s: &Status{
+				Hostname:    "example",
+				LoadPercent: 13.0,
+			},
+			errs: []*ParseError{
+				{Key: "LINEV", Value: "foo Volts", Err: numErr},
+				{Value: "garbage", Err: ErrInvalidKeyValuePair},
+				{Key: "TIMELEFT", Value: "1", Err: ErrInvalidDuration},
+			},
+		},
+		{
+			desc: "strict",
+			mode: ParseStrict,
+			s: &Status{
+				Hostname:    "example",
+				LoadPercent: 13.0,
+			},
+			errs: []*ParseError{
+				{Key: "LINEV", Value: "foo Volts", Err: numErr},
+				{Value: "garbage", Err: ErrInvalidKeyValuePair},
+				{Key: "FOO", Value: "bar", Err: ErrUnknownKey},
+				{Key: "TIMELEFT", Value: "1", Err: ErrInvalidDuration},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			p := newStatusParser(new(Status), tt.mode)
+
+			var err error
+			for _, kv := range kvs {
+				if err = p.parse(kv); err != nil {
+					break
+				}
+			}
+
+			if tt.err != nil {
+				if diff := cmp.Diff(tt.err.Error(), err.Error()); diff != "" {
+					t.Fatalf("unexpected error (-want +got):\n%s", diff)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+
+			s, err := p.result()
+
+			var errs ParseErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("expected ParseErrors, but got: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.s, s, cmpopts.IgnoreFields(Status{}, "Raw")); diff != "" {
+				t.Fatalf("unexpected status (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tt.errs, []*ParseError(errs), cmp.Comparer(func(x, y error) bool {
+				return x.Error() == y.Error()
+			})); diff != "" {
+				t.Fatalf("unexpected errors (-want +got):\n%s", diff)
+			}
+
+			if errors.Is(err, ErrUnknownKey) != (tt.mode == ParseStrict) {
+				t.Fatalf("unexpected unknown key error: %v", err)
+			}
+		})
+	}
+}
+
+func Test_statusParserOK(t *testing.T) {
+	p := newStatusParser(new(Status), ParseStrict)
+	if err := p.parse("HOSTNAME : example"); err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	if _, err := p.result(); err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package apcupsd
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// defaultConcurrency is the default number of NISes polled at once by a
+// Poller.
+const defaultConcurrency = 16
+
+// A PollerConfig configures a Poller. The zero value is a valid
+// configuration.
+type PollerConfig struct {
+	// Concurrency is the maximum number of NISes polled at once. If zero, a
+	// default of 16 is used.
+	Concurrency int
+
+	// Timeout, if non-zero, bounds the time spent polling each NIS, including
+	// any dialing and retries.
+	Timeout time.Duration
+
+	// Reconnect, if non-nil, configures the ReconnectingClient used for each
+	// NIS.
+	Reconnect *ReconnectConfig
+}
+
+// A PollResult is the result of polling a single NIS.
+type PollResult struct {
+	// The UPS status, or nil if it could not be retrieved. With ParseLenient
+	// or ParseStrict, or if the status has a stale APC header, both Status and
+	// Err may be set.
+	Status *Status
+	// Any error which occurred while polling the NIS.
+	Err error
+}
+
+// A Poller polls the status of many NISes concurrently. A Poller maintains a
+// ReconnectingClient for each NIS address it is polling, so connections may be
+// reused between polls if configured. It is safe for concurrent use.
+type Poller struct {
+	network string
+	cfg     PollerConfig
+
+	mu      sync.Mutex
+	clients map[string]*pollerClient
+	closed  bool
+}
+
+// A pollerClient is a ReconnectingClient and the number of polls using it.
+type pollerClient struct {
+	rc     *ReconnectingClient
+	active int
+}
+
+// NewPoller creates a Poller which dials NISes on the named network. If cfg
+// is nil, a default configuration is used.
+//
+// Typically, network will be one of: "tcp", "tcp4", or "tcp6".
+func NewPoller(network string, cfg *PollerConfig) *Poller {
+	if cfg == nil {
+		cfg = &PollerConfig{}
+	}
+
+	return &Poller{
+		network: network,
+		cfg:     *cfg,
+		clients: make(map[string]*pollerClient),
+	}
+}
+
+// Poll retrieves the status of each NIS in addrs concurrently, and returns a
+// map of address to result. Duplicate addresses are polled once. Poll returns
+// once every NIS has been polled or ctx is canceled, in which case the
+// results for any remaining NISes contain the context's error.
+//
+// The connections to any NISes which were polled previously but are missing
+// from addrs are closed, unless they are in use by a concurrent Poll.
+func (p *Poller) Poll(ctx context.Context, addrs []string) map[string]PollResult {
+	p.prune(addrs)
+
+	n := p.cfg.Concurrency
+	if n <= 0 {
+		n = defaultConcurrency
+	}
+
+	var (
+		sem = make(chan struct{}, n)
+		wg  sync.WaitGroup
+
+		mu      sync.Mutex
+		results = make(map[string]PollResult, len(addrs))
+	)
+
+	for _, addr := range addrs {
+		mu.Lock()
+		_, ok := results[addr]
+		if !ok {
+			// Reserve the address to skip any duplicates.
+			results[addr] = PollResult{}
+		}
+		mu.Unlock()
+		if ok {
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			results[addr] = PollResult{Err: ctx.Err()}
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(addr string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			s, err := p.poll(ctx, addr)
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[addr] = PollResult{Status: s, Err: err}
+		}(addr)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// Close closes the connections to all NISes. After Close is called, all
+// polls return errors.
+func (p *Poller) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+
+	var err error
+	for addr, pc := range p.clients {
+		if cerr := pc.rc.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+		delete(p.clients, addr)
+	}
+
+	return err
+}
+
+// prune closes and removes the clients for any addresses which are not in
+// addrs and are not in use.
+func (p *Poller) prune(addrs []string) {
+	keep := make(map[string]bool, len(addrs))
+	for _, addr := range addrs {
+		keep[addr] = true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for addr, pc := range p.clients {
+		if keep[addr] || pc.active > 0 {
+			continue
+		}
+
+		_ = pc.rc.Close()
+		delete(p.clients, addr)
+	}
+}
+
+// poll retrieves the status of a single NIS.
+func (p *Poller) poll(ctx context.Context, addr string) (*Status, error) {
+	rc, err := p.acquire(addr)
+	if err != nil {
+		return nil, err
+	}
+	defer p.release(addr)
+
+	if p.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.cfg.Timeout)
+		defer cancel()
+	}
+
+	return rc.StatusContext(ctx)
+}
+
+// acquire returns the ReconnectingClient for addr, creating it if needed, and
+// marks it in use until release is called.
+func (p *Poller) acquire(addr string) (*ReconnectingClient, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, net.ErrClosed
+	}
+
+	pc, ok := p.clients[addr]
+	if !ok {
+		pc = &pollerClient{rc: NewReconnectingClient(p.network, addr, p.cfg.Reconnect)}
+		p.clients[addr] = pc
+	}
+
+	pc.active++
+	return pc.rc, nil
+}
+
+// release marks the ReconnectingClient for addr as no longer in use by a poll.
+func (p *Poller) release(addr string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// The client is already gone if the Poller was closed.
+	if pc, ok := p.clients[addr]; ok {
+		pc.active--
+	}
+}
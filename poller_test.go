@@ -0,0 +1,156 @@
+package apcupsd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPoller(t *testing.T) {
+	var (
+		a = testServer(t, &testProvider{s: &Status{Hostname: "a"}})
+		b = testServer(t, &testProvider{s: &Status{Hostname: "b"}})
+		c = testServer(t, &testProvider{s: &Status{Hostname: "c"}})
+	)
+
+	// Find an address with nothing listening.
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	bad := l.Addr().String()
+	_ = l.Close()
+
+	p := NewPoller("tcp", &PollerConfig{
+		Concurrency: 2,
+		Timeout:     5 * time.Second,
+		Reconnect:   &ReconnectConfig{Reuse: true},
+	})
+	defer p.Close()
+
+	// Poll repeatedly to exercise connection reuse.
+	for i := 0; i < 2; i++ {
+		results := p.Poll(context.Background(), []string{a, b, c, a, bad})
+		if len(results) != 4 {
+			t.Fatalf("unexpected number of results: %d", len(results))
+		}
+
+		got := make(map[string]string)
+		for _, addr := range []string{a, b, c} {
+			r := results[addr]
+			if r.Err != nil {
+				t.Fatalf("failed to poll %s: %v", addr, r.Err)
+			}
+			got[addr] = r.Status.Hostname
+		}
+
+		want := map[string]string{a: "a", b: "b", c: "c"}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("unexpected hostnames (-want +got):\n%s", diff)
+		}
+
+		var nerr *net.OpError
+		if r := results[bad]; r.Status != nil || !errors.As(r.Err, &nerr) {
+			t.Fatalf("expected dial error, but got: %#v", r)
+		}
+	}
+}
+
+func TestPollerTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	// Accept connections but never respond, as a hung NIS would.
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		_ = l.Close()
+		wg.Wait()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer c.Close()
+				<-done
+			}()
+		}
+	}()
+
+	p := NewPoller("tcp", &PollerConfig{Timeout: 50 * time.Millisecond})
+	defer p.Close()
+
+	results := p.Poll(context.Background(), []string{l.Addr().String()})
+	if r := results[l.Addr().String()]; !errors.Is(r.Err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, but got: %v", r.Err)
+	}
+}
+
+func TestPollerClosed(t *testing.T) {
+	p := NewPoller("tcp", nil)
+	if err := p.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	results := p.Poll(context.Background(), []string{"localhost:0"})
+	if r := results["localhost:0"]; !errors.Is(r.Err, net.ErrClosed) {
+		t.Fatalf("expected closed error, but got: %v", r.Err)
+	}
+}
+
+func TestPollerPrune(t *testing.T) {
+	var (
+		a = testServer(t, &testProvider{s: &Status{Hostname: "a"}})
+		b = testServer(t, &testProvider{s: &Status{Hostname: "b"}})
+	)
+
+	p := NewPoller("tcp", &PollerConfig{
+		Reconnect: &ReconnectConfig{Reuse: true},
+	})
+	defer p.Close()
+
+	_ = p.Poll(context.Background(), []string{a, b})
+
+	p.mu.Lock()
+	rc := p.clients[b].rc
+	p.mu.Unlock()
+
+	// b is no longer polled, so its client is closed and forgotten.
+	results := p.Poll(context.Background(), []string{a})
+	if r := results[a]; r.Err != nil || r.Status.Hostname != "a" {
+		t.Fatalf("unexpected result: %#v", r)
+	}
+
+	p.mu.Lock()
+	got := make(map[string]int)
+	for addr, pc := range p.clients {
+		got[addr] = pc.active
+	}
+	p.mu.Unlock()
+
+	if diff := cmp.Diff(map[string]int{a: 0}, got); diff != "" {
+		t.Fatalf("unexpected clients (-want +got):\n%s", diff)
+	}
+
+	if _, err := rc.Status(); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("expected closed error, but got: %v", err)
+	}
+}
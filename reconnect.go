@@ -0,0 +1,280 @@
+package apcupsd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// A ReconnectConfig configures a ReconnectingClient. The zero value is a
+// valid configuration which dials a new connection for each request and does
+// not retry failed requests.
+type ReconnectConfig struct {
+	// Dial, if non-nil, is used to dial connections to the NIS. If nil,
+	// connections are dialed using DialContext.
+	Dial func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// Reuse keeps a connection open after a successful request so it can be
+	// used for the next request. If a reused connection fails, a new
+	// connection is dialed immediately without counting as a retry.
+	//
+	// apcupsd's NIS may close connections after each request, in which case
+	// Reuse offers no benefit.
+	Reuse bool
+
+	// MaxRetries is the number of times a failed request will be retried
+	// using a new connection. Parse errors, complete statuses with a stale APC
+	// header, and context cancelation are never retried.
+	MaxRetries int
+
+	// Backoff, if non-nil, returns how long to wait before the specified
+	// retry, starting at 1. If nil, retries use exponential backoff starting
+	// at 100 milliseconds and capped at 5 seconds.
+	Backoff func(retry int) time.Duration
+
+	// ParseMode is used to parse each Status. The zero value is
+	// ParseFailFast.
+	ParseMode ParseMode
+
+	// MaxRecordSize is the maximum size of a single record received from the
+	// NIS. See Client.MaxRecordSize for details.
+	MaxRecordSize int
+}
+
+// A ReconnectingClient is a client for a NIS which transparently dials
+// connections as needed, so that a single long-lived ReconnectingClient can
+// be used for the lifetime of a program. It is safe for concurrent use, but
+// requests are serialized.
+type ReconnectingClient struct {
+	network, addr string
+	cfg           ReconnectConfig
+
+	mu     sync.Mutex
+	c      *Client
+	closed bool
+}
+
+// NewReconnectingClient creates a ReconnectingClient which dials a NIS using
+// the address on the named network. No connection is dialed until the first
+// request. If cfg is nil, a default configuration is used.
+//
+// Typically, network will be one of: "tcp", "tcp4", or "tcp6".
+func NewReconnectingClient(network, addr string, cfg *ReconnectConfig) *ReconnectingClient {
+	if cfg == nil {
+		cfg = &ReconnectConfig{}
+	}
+
+	return &ReconnectingClient{
+		network: network,
+		addr:    addr,
+		cfg:     *cfg,
+	}
+}
+
+// Close closes any open connection. After Close is called, all requests
+// return an error.
+func (rc *ReconnectingClient) Close() error {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.closed = true
+	return rc.reset()
+}
+
+// Status retrieves the current UPS status from the NIS.
+func (rc *ReconnectingClient) Status() (*Status, error) {
+	return rc.StatusContext(context.Background())
+}
+
+// StatusContext is like Status, but takes a context which bounds the lifetime
+// of the request, including any dialing, retries, and backoff.
+//
+// The Status and any errors are returned as described for Client.Status. If
+// the ParseMode is ParseLenient or ParseStrict and any key/value pairs could
+// not be parsed, the partially populated Status is returned along with an error
+// which is, or wraps, ParseErrors.
+func (rc *ReconnectingClient) StatusContext(ctx context.Context) (*Status, error) {
+	var s *Status
+	err := rc.do(ctx, func(c *Client) error {
+		var err error
+		s, err = c.StatusContext(ctx)
+		return err
+	})
+
+	return s, err
+}
+
+// StatusInto is like StatusContext, but decodes the status into s. See
+// Client.StatusInto for details.
+func (rc *ReconnectingClient) StatusInto(ctx context.Context, s *Status) error {
+	return rc.do(ctx, func(c *Client) error {
+		return c.StatusInto(ctx, s)
+	})
+}
+
+// Events retrieves the event log from the NIS, oldest event first.
+func (rc *ReconnectingClient) Events() ([]Event, error) {
+	return rc.EventsContext(context.Background())
+}
+
+// EventsContext is like Events, but takes a context which bounds the lifetime
+// of the request, including any dialing, retries, and backoff.
+func (rc *ReconnectingClient) EventsContext(ctx context.Context) ([]Event, error) {
+	var events []Event
+	err := rc.do(ctx, func(c *Client) error {
+		var err error
+		events, err = c.EventsContext(ctx)
+		return err
+	})
+
+	return events, err
+}
+
+// do performs a request using fn, dialing and retrying as configured.
+func (rc *ReconnectingClient) do(ctx context.Context, fn func(c *Client) error) error {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.closed {
+		return net.ErrClosed
+	}
+
+	var err error
+	for retry := 0; retry <= rc.cfg.MaxRetries; retry++ {
+		if retry > 0 {
+			if err := sleep(ctx, rc.backoff(retry)); err != nil {
+				return err
+			}
+		}
+
+		var reused bool
+		reused, err = rc.try(ctx, fn)
+		if err != nil && reused && !isFinal(ctx, err) {
+			// The reused connection may have been closed by the NIS, so
+			// immediately try again with a new one.
+			_, err = rc.try(ctx, fn)
+		}
+		if err == nil || isFinal(ctx, err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// try performs a single request using fn on an existing or newly dialed
+// connection. It reports whether an existing connection was reused.
+func (rc *ReconnectingClient) try(ctx context.Context, fn func(c *Client) error) (bool, error) {
+	reused := rc.c != nil
+	if !reused {
+		c, err := rc.dial(ctx)
+		if err != nil {
+			return false, err
+		}
+
+		rc.c = c
+	}
+
+	err := fn(rc.c)
+	if err != nil || !rc.cfg.Reuse {
+		// Never reuse a connection after an error, since the framing of the
+		// connection may no longer be trusted.
+		_ = rc.reset()
+	}
+
+	return reused, err
+}
+
+// dial dials a new Client.
+func (rc *ReconnectingClient) dial(ctx context.Context) (*Client, error) {
+	var c *Client
+	if rc.cfg.Dial != nil {
+		conn, err := rc.cfg.Dial(ctx, rc.network, rc.addr)
+		if err != nil {
+			return nil, err
+		}
+
+		c = New(conn)
+	} else {
+		var err error
+		c, err = DialContext(ctx, rc.network, rc.addr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.ParseMode = rc.cfg.ParseMode
+	c.MaxRecordSize = rc.cfg.MaxRecordSize
+	return c, nil
+}
+
+// reset closes and discards the current connection, if any.
+func (rc *ReconnectingClient) reset() error {
+	if rc.c == nil {
+		return nil
+	}
+
+	err := rc.c.Close()
+	rc.c = nil
+	return err
+}
+
+// backoff returns the delay before the specified retry.
+func (rc *ReconnectingClient) backoff(retry int) time.Duration {
+	if rc.cfg.Backoff != nil {
+		return rc.cfg.Backoff(retry)
+	}
+
+	const (
+		minBackoff = 100 * time.Millisecond
+		maxBackoff = 5 * time.Second
+	)
+
+	// Avoid overflow by capping the shift.
+	if retry > 10 {
+		return maxBackoff
+	}
+
+	if d := minBackoff << (retry - 1); d < maxBackoff {
+		return d
+	}
+
+	return maxBackoff
+}
+
+// isFinal reports whether err must not be retried.
+func isFinal(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+
+	// The same response would fail to parse again, and a complete status
+	// with a stale header would likely be sent again.
+	var (
+		perr  *ParseError
+		perrs ParseErrors
+		ierr  *IncompleteStatusError
+	)
+
+	return errors.As(err, &perr) || errors.As(err, &perrs) ||
+		(errors.As(err, &ierr) && !ierr.truncated())
+}
+
+// sleep waits for d or until ctx is canceled.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,262 @@
+package apcupsd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReconnectingClient(t *testing.T) {
+	tests := []struct {
+		desc  string
+		reuse bool
+		dials int
+	}{
+		{
+			desc:  "dial per request",
+			dials: 3,
+		},
+		{
+			desc:  "reuse",
+			reuse: true,
+			dials: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			addr := testServer(t, &testProvider{s: &Status{Hostname: "example"}})
+
+			d := &testDialer{}
+			rc := NewReconnectingClient("tcp", addr, &ReconnectConfig{
+				Dial:  d.Dial,
+				Reuse: tt.reuse,
+			})
+			defer rc.Close()
+
+			for i := 0; i < 3; i++ {
+				s, err := rc.Status()
+				if err != nil {
+					t.Fatalf("failed to retrieve status: %v", err)
+				}
+				if s.Hostname != "example" {
+					t.Fatalf("unexpected hostname: %q", s.Hostname)
+				}
+			}
+
+			if got := d.count(); got != tt.dials {
+				t.Fatalf("unexpected number of dials: %d", got)
+			}
+		})
+	}
+}
+
+func TestReconnectingClientStatusInto(t *testing.T) {
+	addr := testServer(t, &testProvider{s: &Status{Hostname: "example"}})
+
+	rc := NewReconnectingClient("tcp", addr, &ReconnectConfig{Reuse: true})
+	defer rc.Close()
+
+	s := &Status{Model: "stale"}
+	for i := 0; i < 3; i++ {
+		if err := rc.StatusInto(context.Background(), s); err != nil {
+			t.Fatalf("failed to retrieve status: %v", err)
+		}
+		if s.Hostname != "example" || s.Model != "" {
+			t.Fatalf("unexpected status: %#v", s)
+		}
+	}
+}
+
+func TestReconnectingClientStaleConnection(t *testing.T) {
+	addr := testServer(t, &testProvider{s: &Status{}})
+
+	d := &testDialer{}
+	rc := NewReconnectingClient("tcp", addr, &ReconnectConfig{
+		Dial:  d.Dial,
+		Reuse: true,
+	})
+	defer rc.Close()
+
+	if _, err := rc.Status(); err != nil {
+		t.Fatalf("failed to retrieve status: %v", err)
+	}
+
+	// Break the reused connection; the next request must transparently
+	// redial even though no retries are configured.
+	_ = d.last().Close()
+
+	if _, err := rc.Status(); err != nil {
+		t.Fatalf("failed to retrieve status after stale connection: %v", err)
+	}
+
+	if got := d.count(); got != 2 {
+		t.Fatalf("unexpected number of dials: %d", got)
+	}
+}
+
+func TestReconnectingClientRetries(t *testing.T) {
+	addr := testServer(t, &testProvider{s: &Status{}})
+
+	errDial := errors.New("dial failed")
+	d := &testDialer{fail: 2, err: errDial}
+
+	var backoffs []int
+	cfg := &ReconnectConfig{
+		Dial:       d.Dial,
+		MaxRetries: 1,
+		Backoff: func(retry int) time.Duration {
+			backoffs = append(backoffs, retry)
+			return 0
+		},
+	}
+
+	rc := NewReconnectingClient("tcp", addr, cfg)
+	defer rc.Close()
+
+	// One retry is not enough to overcome two dial failures.
+	if _, err := rc.Status(); !errors.Is(err, errDial) {
+		t.Fatalf("expected dial error, but got: %v", err)
+	}
+
+	if _, err := rc.Status(); err != nil {
+		t.Fatalf("failed to retrieve status: %v", err)
+	}
+
+	if len(backoffs) != 1 || backoffs[0] != 1 {
+		t.Fatalf("unexpected backoffs: %v", backoffs)
+	}
+}
+
+func TestReconnectingClientStaleHeader(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		dials int
+	)
+
+	// The status is complete, but its header does not match it.
+	rc := NewReconnectingClient("tcp", "localhost:0", &ReconnectConfig{
+		Dial: func(_ context.Context, _, _ string) (net.Conn, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			dials++
+
+			c1, c2 := net.Pipe()
+			go func() {
+				defer c2.Close()
+
+				nc := NewConn(c2)
+				if _, err := nc.ReadRecord(make([]byte, maxString)); err != nil {
+					return
+				}
+
+				for _, r := range []string{
+					"APC      : 001,002,0050\n",
+					"HOSTNAME : example\n",
+					"LINEV    : 120.0 Volts\n",
+					"END APC  : 2016-09-06 22:13:49 -0400\n",
+					"",
+				} {
+					if err := nc.WriteRecord([]byte(r)); err != nil {
+						return
+					}
+				}
+			}()
+
+			return c1, nil
+		},
+		MaxRetries: 3,
+		Backoff:    func(int) time.Duration { return 0 },
+	})
+	defer rc.Close()
+
+	s, err := rc.Status()
+
+	var ierr *IncompleteStatusError
+	if !errors.As(err, &ierr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected stale header, but got: %v", err)
+	}
+	if s == nil || s.Hostname != "example" {
+		t.Fatalf("expected status, but got: %#v", s)
+	}
+
+	// The same status would be sent again, so it is not retried.
+	mu.Lock()
+	defer mu.Unlock()
+	if dials != 1 {
+		t.Fatalf("unexpected number of dials: %d", dials)
+	}
+}
+
+func TestReconnectingClientContext(t *testing.T) {
+	d := &testDialer{fail: 100, err: errors.New("dial failed")}
+	rc := NewReconnectingClient("tcp", "localhost:0", &ReconnectConfig{
+		Dial:       d.Dial,
+		MaxRetries: 100,
+		Backoff:    func(int) time.Duration { return time.Hour },
+	})
+	defer rc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := rc.EventsContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, but got: %v", err)
+	}
+}
+
+func TestReconnectingClientClosed(t *testing.T) {
+	rc := NewReconnectingClient("tcp", "localhost:0", nil)
+	if err := rc.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	if _, err := rc.Status(); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("expected closed error, but got: %v", err)
+	}
+}
+
+// A testDialer counts dials and can fail a number of them.
+type testDialer struct {
+	mu    sync.Mutex
+	fail  int
+	err   error
+	conns []net.Conn
+}
+
+func (d *testDialer) Dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.fail > 0 {
+		d.fail--
+		return nil, d.err
+	}
+
+	var nd net.Dialer
+	c, err := nd.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	d.conns = append(d.conns, c)
+	return c, nil
+}
+
+func (d *testDialer) count() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return len(d.conns)
+}
+
+func (d *testDialer) last() net.Conn {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.conns[len(d.conns)-1]
+}
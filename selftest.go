@@ -0,0 +1,53 @@
+package apcupsd
+
+// A SelftestResult is the result of the most recent UPS self test, as
+// reported by a NIS in the SELFTEST field.
+type SelftestResult string
+
+// Possible SelftestResult values. Values copied from apcupsd source code,
+// v3.14.14.
+const (
+	// Self test indicates a good battery.
+	SelftestOK SelftestResult = "OK"
+	// Self test failed due to insufficient battery capacity.
+	SelftestBatteryFailed SelftestResult = "BT"
+	// Self test failed due to overload.
+	SelftestOverloadFailed SelftestResult = "NG"
+	// No results, i.e. no self test performed in the last 5 minutes.
+	SelftestNone SelftestResult = "NO"
+	// Self test is in progress.
+	SelftestInProgress SelftestResult = "IP"
+	// Self test completed with a warning.
+	SelftestWarning SelftestResult = "WN"
+	// Self test result is unknown.
+	SelftestUnknown SelftestResult = "??"
+)
+
+// Description returns a human readable description of a SelftestResult.
+func (r SelftestResult) Description() string {
+	switch r {
+	case SelftestOK:
+		return "battery OK"
+	case SelftestBatteryFailed:
+		return "failed due to insufficient battery capacity"
+	case SelftestOverloadFailed:
+		return "failed due to overload"
+	case SelftestNone:
+		return "no self test results"
+	case SelftestInProgress:
+		return "self test in progress"
+	case SelftestWarning:
+		return "warning"
+	case SelftestUnknown:
+		return "unknown"
+	case "":
+		return "not reported"
+	default:
+		return "unrecognized result " + string(r)
+	}
+}
+
+// Failed reports whether a SelftestResult indicates that the self test failed.
+func (r SelftestResult) Failed() bool {
+	return r == SelftestBatteryFailed || r == SelftestOverloadFailed
+}
@@ -0,0 +1,33 @@
+package apcupsd
+
+import "testing"
+
+func TestSelftestResult(t *testing.T) {
+	tests := []struct {
+		r      SelftestResult
+		desc   string
+		failed bool
+	}{
+		{r: SelftestOK, desc: "battery OK"},
+		{r: SelftestBatteryFailed, desc: "failed due to insufficient battery capacity", failed: true},
+		{r: SelftestOverloadFailed, desc: "failed due to overload", failed: true},
+		{r: SelftestNone, desc: "no self test results"},
+		{r: SelftestInProgress, desc: "self test in progress"},
+		{r: SelftestWarning, desc: "warning"},
+		{r: SelftestUnknown, desc: "unknown"},
+		{r: "", desc: "not reported"},
+		{r: "XX", desc: "unrecognized result XX"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.r), func(t *testing.T) {
+			if got := tt.r.Description(); got != tt.desc {
+				t.Fatalf("unexpected description: %q", got)
+			}
+
+			if got := tt.r.Failed(); got != tt.failed {
+				t.Fatalf("unexpected failed: %v", got)
+			}
+		})
+	}
+}
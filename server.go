@@ -0,0 +1,214 @@
+package apcupsd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+)
+
+// A Provider provides the UPS status and events served by a Server.
+// Implementations must be safe for concurrent use.
+type Provider interface {
+	// Status returns the current UPS status.
+	Status(ctx context.Context) (*Status, error)
+	// Events returns the event log, oldest event first.
+	Events(ctx context.Context) ([]Event, error)
+}
+
+// A Server is a NIS which serves UPS status and events from a Provider using
+// the apcupsd NIS protocol, so that it appears to clients such as apcaccess
+// as an apcupsd instance.
+type Server struct {
+	p Provider
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	closed bool
+	ls     map[net.Listener]struct{}
+	conns  map[net.Conn]struct{}
+}
+
+// NewServer creates a Server which serves data from p.
+func NewServer(p Provider) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		p: p,
+
+		ctx:    ctx,
+		cancel: cancel,
+
+		ls:    make(map[net.Listener]struct{}),
+		conns: make(map[net.Conn]struct{}),
+	}
+}
+
+// Serve accepts incoming connections on l and serves NIS requests on each
+// connection until the client closes it. If the Provider returns an error, the
+// connection is closed before the end of the response, so that clients report
+// a truncated response. Serve always closes l before
+// returning. Once Close is called, Serve returns nil.
+func (s *Server) Serve(l net.Listener) error {
+	if !s.track(l, nil) {
+		_ = l.Close()
+		return nil
+	}
+	defer func() {
+		s.untrack(l, nil)
+		_ = l.Close()
+	}()
+
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if s.isClosed() && errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return err
+		}
+
+		if !s.track(nil, c) {
+			_ = c.Close()
+			return nil
+		}
+
+		go func() {
+			defer func() {
+				s.untrack(nil, c)
+				_ = c.Close()
+				s.wg.Done()
+			}()
+
+			s.handle(c)
+		}()
+	}
+}
+
+// Close closes all listeners and connections and waits for all in-flight
+// requests to complete.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for l := range s.ls {
+		_ = l.Close()
+	}
+	for c := range s.conns {
+		_ = c.Close()
+	}
+	s.mu.Unlock()
+
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+// handle serves NIS requests on a single connection until an error occurs.
+func (s *Server) handle(c net.Conn) {
+	nc := NewConn(c)
+	b := make([]byte, maxString)
+
+	for {
+		n, err := nc.ReadRecord(b)
+		if err != nil {
+			// Either the client closed the connection or sent an empty
+			// request; in both cases the connection is done.
+			return
+		}
+
+		recs, err := s.respond(string(b[:n]))
+		if err != nil {
+			// The NIS protocol cannot report errors, so close the connection
+			// without ending the response so the client sees a truncated
+			// response rather than a successful empty one.
+			return
+		}
+
+		for _, r := range recs {
+			if err := nc.WriteRecord([]byte(r)); err != nil {
+				return
+			}
+		}
+
+		// Indicate the end of the response with a zero length record.
+		if err := nc.WriteRecord(nil); err != nil {
+			return
+		}
+	}
+}
+
+// respond produces the response records for a single NIS request, or an
+// error if the Provider returns an error.
+func (s *Server) respond(cmd string) ([]string, error) {
+	switch strings.TrimSpace(cmd) {
+	case "status":
+		st, err := s.p.Status(s.ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := st.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+
+		return strings.SplitAfter(strings.TrimSuffix(string(b), "\n"), "\n"), nil
+	case "events":
+		events, err := s.p.Events(s.ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		recs := make([]string, 0, len(events))
+		for _, e := range events {
+			recs = append(recs, e.String()+"\n")
+		}
+
+		return recs, nil
+	default:
+		// Same response as apcupsd.
+		return []string{"Invalid command\n"}, nil
+	}
+}
+
+// track begins tracking a listener or connection, adding connections to the
+// Server's WaitGroup. It returns false if the Server is already closed.
+func (s *Server) track(l net.Listener, c net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	if l != nil {
+		s.ls[l] = struct{}{}
+	}
+	if c != nil {
+		s.conns[c] = struct{}{}
+		s.wg.Add(1)
+	}
+
+	return true
+}
+
+// untrack stops tracking a listener or connection.
+func (s *Server) untrack(l net.Listener, c net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.ls, l)
+	delete(s.conns, c)
+}
+
+// isClosed reports whether Close has been called.
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.closed
+}
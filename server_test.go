@@ -0,0 +1,203 @@
+package apcupsd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestServer(t *testing.T) {
+	want, err := ParseStatus(strings.NewReader(dumpSNMP))
+	if err != nil {
+		t.Fatalf("failed to parse status: %v", err)
+	}
+
+	events := []Event{
+		{
+			Time:    time.Date(2019, time.May, 10, 3, 14, 55, 0, time.UTC),
+			Message: "Power failure.",
+			Kind:    EventPowerFailure,
+		},
+		{
+			Message: "apcupsd exiting, signal 15",
+			Kind:    EventExit,
+		},
+	}
+
+	addr := testServer(t, &testProvider{s: want, events: events})
+
+	c, err := Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+
+	// A single connection can serve multiple requests.
+	for i := 0; i < 2; i++ {
+		got, err := c.Status()
+		if err != nil {
+			t.Fatalf("failed to retrieve status: %v", err)
+		}
+
+		// The APC header is recomputed by the Server.
+		opts := []cmp.Option{
+			cmpopts.IgnoreFields(Status{}, "APC", "Header"),
+			cmpopts.IgnoreSliceElements(func(kv KeyValue) bool { return kv.Key == "APC" }),
+			cmpopts.SortSlices(func(x, y KeyValue) bool { return x.Key < y.Key }),
+		}
+
+		if diff := cmp.Diff(want, got, opts...); diff != "" {
+			t.Fatalf("unexpected Status (-want +got):\n%s", diff)
+		}
+	}
+
+	got, err := c.Events()
+	if err != nil {
+		t.Fatalf("failed to retrieve events: %v", err)
+	}
+
+	if diff := cmp.Diff(events, got); diff != "" {
+		t.Fatalf("unexpected Events (-want +got):\n%s", diff)
+	}
+}
+
+func TestServerInvalidCommand(t *testing.T) {
+	addr := testServer(t, &testProvider{s: &Status{}})
+
+	c, err := Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+
+	var got []string
+	err = c.command(context.Background(), "foo", func(record []byte) error {
+		got = append(got, string(record))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to send command: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"Invalid command\n"}, got); diff != "" {
+		t.Fatalf("unexpected response (-want +got):\n%s", diff)
+	}
+}
+
+func TestServerProviderError(t *testing.T) {
+	addr := testServer(t, &testProvider{err: errors.New("no UPS")})
+
+	c, err := Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+
+	// The failure must not appear to be a successful empty status.
+	s, err := c.Status()
+	var terr *TruncatedResponseError
+	if !errors.As(err, &terr) || terr.Records != 0 || s != nil {
+		t.Fatalf("expected truncated response, but got: %#v, %v", s, err)
+	}
+
+	c, err = Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.Events(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected truncated response, but got: %v", err)
+	}
+}
+
+func TestServerClose(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	srv := NewServer(&testProvider{s: &Status{}})
+
+	errC := make(chan error, 1)
+	go func() { errC <- srv.Serve(l) }()
+
+	// Hold a connection open to ensure Close does not block on it.
+	c, err := Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.Status(); err != nil {
+		t.Fatalf("failed to retrieve status: %v", err)
+	}
+
+	if err := srv.Close(); err != nil {
+		t.Fatalf("failed to close server: %v", err)
+	}
+
+	if err := <-errC; err != nil {
+		t.Fatalf("failed to serve: %v", err)
+	}
+}
+
+// testServer starts a Server for p and returns its address.
+func testServer(t *testing.T, p Provider) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	srv := NewServer(p)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := srv.Serve(l); err != nil {
+			panicf("failed to serve: %v", err)
+		}
+	}()
+
+	t.Cleanup(func() {
+		_ = srv.Close()
+		wg.Wait()
+	})
+
+	return l.Addr().String()
+}
+
+var _ Provider = &testProvider{}
+
+// A testProvider is a Provider which returns fixed data.
+type testProvider struct {
+	mu     sync.Mutex
+	s      *Status
+	events []Event
+	err    error
+}
+
+func (p *testProvider) Status(_ context.Context) (*Status, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.s, p.err
+}
+
+func (p *testProvider) Events(_ context.Context) ([]Event, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.events, p.err
+}
@@ -0,0 +1,98 @@
+package apcupsd
+
+import "strings"
+
+// A State is a single UPS operating state token, as reported by a NIS in the
+// STATUS field.
+type State string
+
+// Possible State values. Values copied from apcupsd source code, v3.14.14.
+const (
+	StateCalibration    State = "CAL"
+	StateTrim           State = "TRIM"
+	StateBoost          State = "BOOST"
+	StateOnline         State = "ONLINE"
+	StateOnBattery      State = "ONBATT"
+	StateOverload       State = "OVERLOAD"
+	StateLowBattery     State = "LOWBATT"
+	StateReplaceBattery State = "REPLACEBATT"
+	StateNoBattery      State = "NOBATT"
+	StateSlave          State = "SLAVE"
+	StateSlaveDown      State = "SLAVEDOWN"
+	StateCommLost       State = "COMMLOST"
+	StateShuttingDown   State = "SHUTTING DOWN"
+)
+
+// States is the set of State tokens reported by a NIS in the STATUS field,
+// in the order they were reported.
+type States []State
+
+// ParseStates parses a STATUS value, such as "ONBATT LOWBATT", into its
+// individual State tokens. Unrecognized tokens are preserved as-is.
+func ParseStates(status string) States {
+	fields := strings.Fields(status)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	ss := make(States, 0, len(fields))
+	for i := 0; i < len(fields); i++ {
+		// "SHUTTING DOWN" is the only state which contains a space, so
+		// rejoin its halves into a single token.
+		if fields[i] == "SHUTTING" && i+1 < len(fields) && fields[i+1] == "DOWN" {
+			ss = append(ss, StateShuttingDown)
+			i++
+			continue
+		}
+
+		ss = append(ss, State(fields[i]))
+	}
+
+	return ss
+}
+
+// Has reports whether state is present in ss.
+func (ss States) Has(state State) bool {
+	for _, s := range ss {
+		if s == state {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Online reports whether the UPS is running on line power.
+func (ss States) Online() bool { return ss.Has(StateOnline) }
+
+// OnBattery reports whether the UPS is running on batteries.
+func (ss States) OnBattery() bool { return ss.Has(StateOnBattery) }
+
+// LowBattery reports whether the UPS battery charge is low.
+func (ss States) LowBattery() bool { return ss.Has(StateLowBattery) }
+
+// ReplaceBattery reports whether the UPS battery must be replaced.
+func (ss States) ReplaceBattery() bool { return ss.Has(StateReplaceBattery) }
+
+// Overload reports whether the UPS output is overloaded.
+func (ss States) Overload() bool { return ss.Has(StateOverload) }
+
+// CommLost reports whether communications with the UPS have been lost.
+func (ss States) CommLost() bool { return ss.Has(StateCommLost) }
+
+// ShuttingDown reports whether a system shutdown is in progress.
+func (ss States) ShuttingDown() bool { return ss.Has(StateShuttingDown) }
+
+// String returns the States in the same space-separated format as the STATUS
+// field.
+func (ss States) String() string {
+	strs := make([]string, 0, len(ss))
+	for _, s := range ss {
+		strs = append(strs, string(s))
+	}
+
+	return strings.Join(strs, " ")
+}
+
+// States parses the Status field into its individual State tokens.
+func (s *Status) States() States { return ParseStates(s.Status) }
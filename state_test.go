@@ -0,0 +1,75 @@
+package apcupsd
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseStates(t *testing.T) {
+	tests := []struct {
+		desc   string
+		status string
+		ss     States
+		onBatt bool
+		lowBat bool
+		comm   bool
+		repl   bool
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc:   "online",
+			status: "ONLINE ",
+			ss:     States{StateOnline},
+		},
+		{
+			desc:   "on battery low battery",
+			status: "ONBATT LOWBATT",
+			ss:     States{StateOnBattery, StateLowBattery},
+			onBatt: true,
+			lowBat: true,
+		},
+		{
+			desc:   "comm lost",
+			status: "COMMLOST",
+			ss:     States{StateCommLost},
+			comm:   true,
+		},
+		{
+			desc:   "shutting down",
+			status: "ONBATT LOWBATT SHUTTING DOWN",
+			ss:     States{StateOnBattery, StateLowBattery, StateShuttingDown},
+			onBatt: true,
+			lowBat: true,
+		},
+		{
+			desc:   "replace battery and unknown",
+			status: "ONLINE REPLACEBATT FOO",
+			ss:     States{StateOnline, StateReplaceBattery, "FOO"},
+			repl:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			s := &Status{Status: tt.status}
+			ss := s.States()
+
+			if diff := cmp.Diff(tt.ss, ss); diff != "" {
+				t.Fatalf("unexpected States (-want +got):\n%s", diff)
+			}
+
+			got := []bool{ss.OnBattery(), ss.LowBattery(), ss.CommLost(), ss.ReplaceBattery()}
+			want := []bool{tt.onBatt, tt.lowBat, tt.comm, tt.repl}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf("unexpected predicates (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(ParseStates(ss.String()), ss); diff != "" {
+				t.Fatalf("unexpected round trip States (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
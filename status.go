@@ -1,8 +1,6 @@
 package apcupsd
 
 import (
-	"errors"
-	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -11,24 +9,39 @@ import (
 const (
 	// timeFormatLong is the package time format of long timestamps from a NIS.
 	timeFormatLong = "2006-01-02 15:04:05 -0700"
-)
-
-var (
-	// errInvalidKeyValuePair is returned when a message is not in the expected
-	// "key : value" format.
-	errInvalidKeyValuePair = errors.New("invalid key/value pair")
 
-	// errInvalidDuration is returned when a value is not in the expected
-	// duration format, e.g. "10 Seconds" or "2 minutes".
-	errInvalidDuration = errors.New("invalid time duration")
+	// dateFormat is the time format of dates, such as BATTDATE, from a NIS.
+	dateFormat = "2006-01-02"
 )
 
+// timeFormats is the list of time formats reported by various apcupsd
+// versions and drivers, in the order they are attempted.
+var timeFormats = []string{
+	timeFormatLong,
+	// Older apcupsd versions, e.g. "Mon Sep 05 21:44:09 EDT 2016".
+	time.UnixDate,
+	time.ANSIC,
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	dateFormat,
+	"2006/01/02",
+	"01/02/2006",
+	"01/02/06",
+}
+
 // Status is the status of an APC Uninterruptible Power Supply (UPS), as
 // returned by a NIS.
+//
+// Fields whose key is not reported by the NIS, or whose value could not be
+// parsed, hold their zero value. Use Has to determine whether a field's key was
+// reported with a valid value.
 type Status struct {
 	// Header record indicating the STATUS format revision level, the number of records that follow the
 	// APC statement, and the number of bytes that follow the record.
 	APC string
+	// The parsed form of the APC header record.
+	Header Header
 	// The date and time that the information was last obtained from the UPS.
 	Date time.Time
 	// The name of the machine that collected the UPS data.
@@ -45,14 +58,28 @@ type Status struct {
 	UPSMode string
 	// The time/date that apcupsd was started.
 	StartTime time.Time
+	// The last time the master sent an update to the slave.
+	MasterUpdate time.Time
+	// The network address of the master, when operating as a slave.
+	Master string
+	// The ShareUPS mode of operation.
+	Share string
 	// The UPS model as derived from information from the UPS.
 	Model string
 	// The current status of the UPS (ONLINE, ONBATT, etc.)
 	Status string
+	// The state of the line voltage (OK or DOWN), reported by simple
+	// signalling UPSes.
+	LineFail string
+	// The state of the battery (OK or DOWN), reported by simple signalling
+	// UPSes.
+	BatteryStatus string
 	// The current line voltage as returned by the UPS.
 	LineVoltage float64
 	// The percentage of load capacity as estimated by the UPS.
 	LoadPercent float64
+	// The percentage of apparent power load capacity as estimated by the UPS.
+	LoadApparentPercent float64
 	// The percentage charge on the batteries.
 	BatteryChargePercent float64
 	// The remaining runtime left on batteries as estimated by the UPS.
@@ -66,12 +93,27 @@ type Status struct {
 	// apcupsd will shutdown your system if the time on batteries exceeds this value. A value of zero
 	// disables the feature. Value is set in the configuration file (TIMEOUT)
 	MaximumTime time.Duration
+	// The maximum line voltage since the UPS was started, as returned by the UPS.
+	MaximumLineVoltage float64
+	// The minimum line voltage since the UPS was started, as returned by the UPS.
+	MinimumLineVoltage float64
 	// The sensitivity level of the UPS to line voltage fluctuations.
 	Sense string
+	// The amount of time the UPS will wait before restoring power to the load
+	// when line power returns after a shutdown.
+	WakeDelay time.Duration
+	// The grace delay that the UPS gives after receiving a power down command
+	// from apcupsd before it powers off the load.
+	ShutdownDelay time.Duration
+	// The remaining runtime below which the UPS sends the low battery signal.
+	LowBatteryDelay time.Duration
 	// The line voltage below which the UPS will switch to batteries.
 	LowTransferVoltage float64
 	// The line voltage above which the UPS will switch to batteries.
 	HighTransferVoltage float64
+	// The percentage battery charge necessary for the UPS to return power to
+	// the load after a shutdown.
+	ReturnChargePercent float64
 	// The delay period for the UPS alarm.
 	AlarmDel time.Duration
 	// Battery voltage as supplied by the UPS.
@@ -88,82 +130,184 @@ type Status struct {
 	CumulativeTimeOnBattery time.Duration
 	// Time and date of last transfer from batteries, or N/A.
 	XOffBattery time.Time
-	// The interval in hours between automatic self tests.
+	// The time and date of the last self test.
 	LastSelftest time.Time
-	// The results of the last self test, and may have the following values:
-	// • OK: self test indicates good battery
-	// • BT: self test failed due to insufficient battery capacity
-	// • NG: self test failed due to overload
-	// • NO: No results (i.e. no self test performed in the last 5 minutes)
-	Selftest bool
+	// The results of the last self test, such as SelftestOK or
+	// SelftestBatteryFailed.
+	Selftest SelftestResult
+	// The interval in hours between automatic self tests, or a setting such
+	// as OFF.
+	SelftestInterval string
 	// Status flag. English version is given by STATUS.
-	StatusFlags string
+	StatusFlags StatusFlag
+	// The current dip switch settings on UPSes that have them.
+	DipSwitch uint8
+	// The values of the UPS fault registers 1, 2, and 3.
+	Register1, Register2, Register3 uint8
+	// The date the UPS was manufactured.
+	ManufactureDate time.Time
 	// The UPS serial number
 	SerialNumber string
 	// The date that batteries were last replaced
-	BatteryDate string
+	BatteryDate time.Time
+	// The output voltage that the UPS will attempt to supply when on battery
+	// power.
+	NominalOutputVoltage float64
 	// The input voltage that the UPS is configured to expect.
 	NominalInputVoltage float64
 	// The nominal battery voltage.
 	NominalBatteryVoltage float64
 	// The maximum power in Watts that the UPS is designed to supply.
 	NominalPower int
+	// The maximum apparent power in Volt-Amperes that the UPS is designed to
+	// supply.
+	NominalApparentPower int
+	// The humidity percentage as measured by the UPS.
+	Humidity float64
+	// The ambient temperature in degrees Celsius as measured by the UPS.
+	AmbientTemp float64
+	// The number of external batteries as defined by the user.
+	ExternalBatteries int
+	// The number of bad battery packs.
+	BadBatteries int
 	// The firmware revision number as reported by the UPS.
 	Firmware string
+	// The old APC model identification code.
+	APCModel string
 	// The time and date that the STATUS record was written.
 	EndAPC time.Time
-	// The ambient temperature as measured by the UPS.
-	InternalTemp  float64
+	// The internal temperature in degrees Celsius as measured by the UPS.
+	InternalTemp float64
+	// The voltage the UPS is supplying to the load.
 	OutputVoltage float64
+	// The line frequency in Hertz as given by the UPS.
 	LineFrequency float64
-	OutputAmps    float64
+	// The output current in Amperes as given by the UPS.
+	OutputAmps float64
+
+	// Every key/value pair reported by the NIS, in the order they were
+	// received, including those which do not correspond to a field above.
+	Raw []KeyValue
+}
+
+// A KeyValue is a raw key/value pair reported by a NIS.
+type KeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Lookup returns the raw value reported for key, such as "LINEV" or a
+// driver-specific key, and whether the key was present. If the key was
+// reported more than once, the first value is returned.
+func (s *Status) Lookup(key string) (string, bool) {
+	for _, kv := range s.Raw {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// Has reports whether key, such as "ITEMP" or "OUTPUTV", was reported by the
+// NIS with a valid value. Status fields hold their zero value when their key
+// is not reported or its value could not be parsed, so Has distinguishes such
+// a field from a reported value of zero. Has relies on s.Raw, so it always
+// reports false for a Status which was not parsed from NIS output.
+func (s *Status) Has(k string) bool {
+	// The last value reported for a key determines its field.
+	for i := len(s.Raw) - 1; i >= 0; i-- {
+		if s.Raw[i].Key != k {
+			continue
+		}
+
+		var scratch Status
+		_, err := scratch.setField(key(k), s.Raw[i].Value)
+		return err == nil
+	}
+
+	return false
 }
 
 // parseKV parses an input key/value string in "key : value" format, and sets
 // the appropriate struct field from the input data.
 func (s *Status) parseKV(kv string) error {
-	sp := strings.SplitN(kv, ":", 2)
-	if len(sp) != 2 {
-		return errInvalidKeyValuePair
+	k, v, err := splitKV(kv)
+	if err != nil {
+		return err
 	}
 
-	var (
-		k = key(strings.TrimSpace(sp[0]))
-		v = strings.TrimSpace(sp[1])
-	)
+	_, err = s.setKV(k, v)
+	return err
+}
 
+// splitKV splits an input key/value string in "key : value" format into its
+// trimmed key and value.
+func splitKV(kv string) (key, string, error) {
+	k, v, ok := strings.Cut(kv, ":")
+	if !ok {
+		return "", "", ErrInvalidKeyValuePair
+	}
+
+	return key(strings.TrimSpace(k)), strings.TrimSpace(v), nil
+}
+
+// setKV records a raw key/value pair and sets the appropriate struct field
+// from the value. It returns true if the key was matched, and false if not.
+func (s *Status) setKV(k key, v string) (bool, error) {
+	s.Raw = append(s.Raw, KeyValue{Key: string(k), Value: v})
+	return s.setField(k, v)
+}
+
+// setField sets the appropriate struct field from a value. It returns true if
+// the key was matched, and false if not.
+func (s *Status) setField(k key, v string) (bool, error) {
 	// Attempt to match various common data types.
 
 	if match := s.parseKVString(k, v); match {
-		return nil
+		return true, nil
 	}
 
 	if match, err := s.parseKVFloat(k, v); match {
-		return err
+		return true, err
 	}
 
 	if match, err := s.parseKVTime(k, v); match {
-		return err
+		return true, err
 	}
 
 	if match, err := s.parseKVDuration(k, v); match {
-		return err
+		return true, err
+	}
+
+	if match, err := s.parseKVInt(k, v); match {
+		return true, err
 	}
 
 	// Attempt to match uncommon data types.
 
 	var err error
 	switch k {
-	case keyNumXfers:
-		s.NumberTransfers, err = strconv.Atoi(v)
-	case keyNomPower:
-		f := strings.SplitN(v, " ", 2)
-		s.NominalPower, err = strconv.Atoi(f[0])
+	case keyAPC:
+		s.APC = v
+		s.Header, err = parseHeader(v)
+	case keyDipSw:
+		s.DipSwitch, err = parseRegister(v)
+	case keyReg1:
+		s.Register1, err = parseRegister(v)
+	case keyReg2:
+		s.Register2, err = parseRegister(v)
+	case keyReg3:
+		s.Register3, err = parseRegister(v)
 	case keySelftest:
-		s.Selftest = v == "YES"
+		s.Selftest = SelftestResult(v)
+	case keyStatFlag:
+		s.StatusFlags, err = parseStatusFlag(v)
+	default:
+		return false, nil
 	}
 
-	return err
+	return true, err
 }
 
 // TODO(mdlayher): rework parsing code and add enumcheck.
@@ -174,41 +318,66 @@ type key string
 // List of keys sent by a NIS, used to map values to Status fields.
 const (
 	keyAlarmDel      key = "ALARMDEL"
+	keyAmbTemp       key = "AMBTEMP"
 	keyAPC           key = "APC"
+	keyAPCModel      key = "APCMODEL"
+	keyBadBatts      key = "BADBATTS"
 	keyBattDate      key = "BATTDATE"
+	keyBattStat      key = "BATTSTAT"
 	keyBattV         key = "BATTV"
 	keyBCharge       key = "BCHARGE"
 	keyCable         key = "CABLE"
 	keyCumOnBatt     key = "CUMONBATT"
 	keyDate          key = "DATE"
+	keyDipSw         key = "DIPSW"
+	keyDLowBatt      key = "DLOWBATT"
 	keyDriver        key = "DRIVER"
+	keyDShutd        key = "DSHUTD"
+	keyDWake         key = "DWAKE"
 	keyEndAPC        key = "END APC"
+	keyExtBatts      key = "EXTBATTS"
 	keyFirmware      key = "FIRMWARE"
 	keyHiTrans       key = "HITRANS"
 	keyHostname      key = "HOSTNAME"
+	keyHumidity      key = "HUMIDITY"
 	keyITemp         key = "ITEMP"
 	keyLastStest     key = "LASTSTEST"
 	keyLastXfer      key = "LASTXFER"
+	keyLineFail      key = "LINEFAIL"
 	keyLineFrequency key = "LINEFREQ"
 	keyLineV         key = "LINEV"
+	keyLoadAPnt      key = "LOADAPNT"
 	keyLoadPct       key = "LOADPCT"
 	keyLoTrans       key = "LOTRANS"
+	keyManDate       key = "MANDATE"
+	keyMaster        key = "MASTER"
+	keyMasterUpd     key = "MASTERUPD"
+	keyMaxLineV      key = "MAXLINEV"
 	keyMaxTime       key = "MAXTIME"
 	keyMBattChg      key = "MBATTCHG"
+	keyMinLineV      key = "MINLINEV"
 	keyMinTimeL      key = "MINTIMEL"
 	keyModel         key = "MODEL"
+	keyNomAPnt       key = "NOMAPNT"
 	keyNomBattV      key = "NOMBATTV"
 	keyNomInV        key = "NOMINV"
+	keyNomOutV       key = "NOMOUTV"
 	keyNomPower      key = "NOMPOWER"
 	keyNumXfers      key = "NUMXFERS"
 	keyOutV          key = "OUTPUTV"
 	keyOutputAmps    key = "OUTCURNT"
+	keyReg1          key = "REG1"
+	keyReg2          key = "REG2"
+	keyReg3          key = "REG3"
+	keyRetPct        key = "RETPCT"
 	keySelftest      key = "SELFTEST"
 	keySense         key = "SENSE"
 	keySerialNo      key = "SERIALNO"
+	keyShare         key = "SHARE"
 	keyStartTime     key = "STARTTIME"
 	keyStatFlag      key = "STATFLAG"
 	keyStatus        key = "STATUS"
+	keyStestI        key = "STESTI"
 	keyTimeLeft      key = "TIMELEFT"
 	keyTOnBatt       key = "TONBATT"
 	keyUPSMode       key = "UPSMODE"
@@ -222,8 +391,6 @@ const (
 // returns true if a field was matched, and false if not.
 func (s *Status) parseKVString(k key, v string) bool {
 	switch k {
-	case keyAPC:
-		s.APC = v
 	case keyHostname:
 		s.Hostname = v
 	case keyVersion:
@@ -244,14 +411,22 @@ func (s *Status) parseKVString(k key, v string) bool {
 		s.Sense = v
 	case keyLastXfer:
 		s.LastTransfer = v
-	case keyStatFlag:
-		s.StatusFlags = v
 	case keySerialNo:
 		s.SerialNumber = v
-	case keyBattDate:
-		s.BatteryDate = v
 	case keyFirmware:
 		s.Firmware = v
+	case keyMaster:
+		s.Master = v
+	case keyShare:
+		s.Share = v
+	case keyLineFail:
+		s.LineFail = v
+	case keyBattStat:
+		s.BatteryStatus = v
+	case keyStestI:
+		s.SelftestInterval = v
+	case keyAPCModel:
+		s.APCModel = v
 	default:
 		return false
 	}
@@ -262,11 +437,9 @@ func (s *Status) parseKVString(k key, v string) bool {
 // parseKVFloat parses a float64 value into the appropriate Status field. It
 // returns true if a field was matched, and false if not.
 func (s *Status) parseKVFloat(k key, v string) (bool, error) {
-	f := strings.SplitN(v, " ", 2)
-
 	// Save repetition for function calls.
 	parse := func() (float64, error) {
-		return strconv.ParseFloat(f[0], 64)
+		return parseFloat(k, v)
 	}
 
 	var err error
@@ -297,6 +470,47 @@ func (s *Status) parseKVFloat(k key, v string) (bool, error) {
 		s.LineFrequency, err = parse()
 	case keyOutputAmps:
 		s.OutputAmps, err = parse()
+	case keyLoadAPnt:
+		s.LoadApparentPercent, err = parse()
+	case keyMaxLineV:
+		s.MaximumLineVoltage, err = parse()
+	case keyMinLineV:
+		s.MinimumLineVoltage, err = parse()
+	case keyRetPct:
+		s.ReturnChargePercent, err = parse()
+	case keyNomOutV:
+		s.NominalOutputVoltage, err = parse()
+	case keyHumidity:
+		s.Humidity, err = parse()
+	case keyAmbTemp:
+		s.AmbientTemp, err = parse()
+	default:
+		return false, nil
+	}
+
+	return true, err
+}
+
+// parseKVInt parses an int value into the appropriate Status field. It
+// returns true if a field was matched, and false if not.
+func (s *Status) parseKVInt(k key, v string) (bool, error) {
+	// Save repetition for function calls.
+	parse := func() (int, error) {
+		return parseInt(k, v)
+	}
+
+	var err error
+	switch k {
+	case keyNumXfers:
+		s.NumberTransfers, err = parse()
+	case keyNomPower:
+		s.NominalPower, err = parse()
+	case keyNomAPnt:
+		s.NominalApparentPower, err = parse()
+	case keyExtBatts:
+		s.ExternalBatteries, err = parse()
+	case keyBadBatts:
+		s.BadBatteries, err = parse()
 	default:
 		return false, nil
 	}
@@ -321,6 +535,12 @@ func (s *Status) parseKVTime(k key, v string) (bool, error) {
 		s.LastSelftest, err = parseOptionalTime(v)
 	case keyEndAPC:
 		s.EndAPC, err = parseOptionalTime(v)
+	case keyMasterUpd:
+		s.MasterUpdate, err = parseOptionalTime(v)
+	case keyBattDate:
+		s.BatteryDate, err = parseOptionalTime(v)
+	case keyManDate:
+		s.ManufactureDate, err = parseOptionalTime(v)
 	default:
 		return false, nil
 	}
@@ -352,6 +572,12 @@ func (s *Status) parseKVDuration(k key, v string) (bool, error) {
 		s.TimeOnBattery, err = parse()
 	case keyCumOnBatt:
 		s.CumulativeTimeOnBattery, err = parse()
+	case keyDWake:
+		s.WakeDelay, err = parse()
+	case keyDShutd:
+		s.ShutdownDelay, err = parse()
+	case keyDLowBatt:
+		s.LowBatteryDelay, err = parse()
 	default:
 		return false, nil
 	}
@@ -359,40 +585,114 @@ func (s *Status) parseKVDuration(k key, v string) (bool, error) {
 	return true, err
 }
 
-// parseDuration parses a duration value returned from a NIS as a time.Duration.
-func parseDuration(d string) (time.Duration, error) {
-	ss := strings.SplitN(d, " ", 2)
-	if len(ss) != 2 {
-		return 0, errInvalidDuration
-	}
+// parseRegister parses a hexadecimal register value, such as "0x00" or
+// "0x00 Register 1", as a uint8.
+func parseRegister(v string) (uint8, error) {
+	f, _, _ := strings.Cut(v, " ")
 
-	var (
-		num  = ss[0]
-		unit = ss[1]
-	)
-
-	// Normalize units into ones that time.ParseDuration expects.
-	switch strings.ToLower(unit) {
-	case "minutes":
-		unit = "m"
-	case "seconds":
-		unit = "s"
+	u, err := strconv.ParseUint(f, 0, 8)
+	if err != nil {
+		return 0, err
 	}
 
-	return time.ParseDuration(fmt.Sprintf("%s%s", num, unit))
+	return uint8(u), nil
 }
 
-// parseOptionalTime parses a time string but also accepts the special value
-// "N/A" (which apcupsd reports for some values and conditions); this value is
-// mapped to time.Time{}. The caller can check for this with time.IsZero().
+// parseOptionalTime parses a time string in any of timeFormats but also
+// accepts the special value "N/A" (which apcupsd reports for some values and
+// conditions); this value is mapped to time.Time{}. The caller can check for
+// this with time.IsZero().
+//
+// apcupsd reports local time, so times without a time zone are interpreted in
+// the local time zone. Time zone abbreviations are interpreted using the local
+// time zone if it uses them, and otherwise using zoneOffsets.
 func parseOptionalTime(value string) (time.Time, error) {
 	if value == "N/A" {
 		return time.Time{}, nil
 	}
 
-	if time, err := time.Parse(timeFormatLong, value); err == nil {
-		return time, nil
+	for _, f := range timeFormats {
+		if !mayParseTime(f, value) {
+			continue
+		}
+
+		if t, err := time.ParseInLocation(f, value, time.Local); err == nil {
+			return fixZone(t)
+		}
+	}
+
+	return time.Time{}, ErrInvalidTime
+}
+
+// zoneOffsets maps common time zone abbreviations to their offsets from UTC
+// in seconds. Ambiguous abbreviations, such as IST, are omitted, except for
+// CST which is interpreted as US Central Standard Time.
+var zoneOffsets = map[string]int{
+	"UTC":  0,
+	"GMT":  0,
+	"WET":  0,
+	"WEST": 1 * 60 * 60,
+	"BST":  1 * 60 * 60,
+	"CET":  1 * 60 * 60,
+	"CEST": 2 * 60 * 60,
+	"EET":  2 * 60 * 60,
+	"EEST": 3 * 60 * 60,
+	"MSK":  3 * 60 * 60,
+	"AWST": 8 * 60 * 60,
+	"JST":  9 * 60 * 60,
+	"KST":  9 * 60 * 60,
+	"ACST": 9*60*60 + 30*60,
+	"ACDT": 10*60*60 + 30*60,
+	"AEST": 10 * 60 * 60,
+	"AEDT": 11 * 60 * 60,
+	"NZST": 12 * 60 * 60,
+	"NZDT": 13 * 60 * 60,
+	"HST":  -10 * 60 * 60,
+	"AKST": -9 * 60 * 60,
+	"AKDT": -8 * 60 * 60,
+	"PST":  -8 * 60 * 60,
+	"PDT":  -7 * 60 * 60,
+	"MST":  -7 * 60 * 60,
+	"MDT":  -6 * 60 * 60,
+	"CST":  -6 * 60 * 60,
+	"CDT":  -5 * 60 * 60,
+	"EST":  -5 * 60 * 60,
+	"EDT":  -4 * 60 * 60,
+	"AST":  -4 * 60 * 60,
+	"ADT":  -3 * 60 * 60,
+}
+
+// fixZone corrects a time parsed with a time zone abbreviation which the local
+// time zone does not use, for which time.ParseInLocation assumes an offset of
+// zero. ErrInvalidTime is returned if the abbreviation is unknown.
+func fixZone(t time.Time) (time.Time, error) {
+	name, offset := t.Zone()
+	if t.Location() == time.Local || t.Location() == time.UTC || name == "" || offset != 0 {
+		// Local, UTC, or numeric offset.
+		return t, nil
+	}
+
+	offset, ok := zoneOffsets[name]
+	if !ok {
+		return time.Time{}, ErrInvalidTime
 	}
 
-	return time.Time{}, fmt.Errorf("can't parse time: %q", value)
+	y, m, d := t.Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.FixedZone(name, offset)), nil
+}
+
+// mayParseTime reports whether value could be a time in layout, one of
+// timeFormats. Attempts which must fail are skipped because each failed
+// attempt allocates an error.
+func mayParseTime(layout, value string) bool {
+	switch layout {
+	case time.UnixDate, time.ANSIC:
+		// Begins with the name of a weekday.
+		return value != "" && (value[0] < '0' || value[0] > '9')
+	case time.RFC3339:
+		return len(value) >= len("2006-01-02T15:04:05Z")
+	default:
+		// The remaining layouts have a fixed length.
+		return len(value) == len(layout)
+	}
 }
@@ -2,10 +2,12 @@ package apcupsd
 
 import (
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 func TestStatus_parseKV(t *testing.T) {
@@ -18,12 +20,12 @@ func TestStatus_parseKV(t *testing.T) {
 		{
 			desc: "invalid format",
 			kv:   "foo",
-			err:  errInvalidKeyValuePair,
+			err:  ErrInvalidKeyValuePair,
 		},
 		{
 			desc: "invalid duration",
 			kv:   "TIMELEFT : 1 ",
-			err:  errInvalidDuration,
+			err:  ErrInvalidDuration,
 		},
 		{
 			desc: "unknown",
@@ -34,7 +36,8 @@ func TestStatus_parseKV(t *testing.T) {
 			desc: "OK string",
 			kv:   "APC : 001,002,0003",
 			s: &Status{
-				APC: "001,002,0003",
+				APC:    "001,002,0003",
+				Header: Header{Format: 1, Records: 2, Bytes: 3},
 			},
 		},
 		{
@@ -81,9 +84,79 @@ func TestStatus_parseKV(t *testing.T) {
 		},
 		{
 			desc: "OK Selftest",
-			kv:   "SELFTEST: YES",
+			kv:   "SELFTEST: OK",
 			s: &Status{
-				Selftest: true,
+				Selftest: SelftestOK,
+			},
+		},
+		{
+			desc: "failed Selftest",
+			kv:   "SELFTEST: BT",
+			s: &Status{
+				Selftest: SelftestBatteryFailed,
+			},
+		},
+		{
+			desc: "OK STATFLAG",
+			kv:   "STATFLAG : 0x05000008",
+			s: &Status{
+				StatusFlags: StatusFlagOnline | StatusFlagPlugged | StatusFlagBatteryPresent,
+			},
+		},
+		{
+			desc: "OK STATFLAG with label",
+			kv:   "STATFLAG : 0x07000010 Status Flag",
+			s: &Status{
+				StatusFlags: StatusFlagOnBattery | StatusFlagPlugged | 0x02000000 | StatusFlagBatteryPresent,
+			},
+		},
+		{
+			desc: "OK LINEFAIL",
+			kv:   "LINEFAIL : OK",
+			s: &Status{
+				LineFail: "OK",
+			},
+		},
+		{
+			desc: "OK BATTSTAT",
+			kv:   "BATTSTAT : OK",
+			s: &Status{
+				BatteryStatus: "OK",
+			},
+		},
+		{
+			desc: "OK SHARE",
+			kv:   "SHARE    : Share Master",
+			s: &Status{
+				Share: "Share Master",
+			},
+		},
+		{
+			desc: "OK DIPSW",
+			kv:   "DIPSW    : 0x02 Dip Switch",
+			s: &Status{
+				DipSwitch: 0x02,
+			},
+		},
+		{
+			desc: "OK REG1",
+			kv:   "REG1     : 0x10 Register 1",
+			s: &Status{
+				Register1: 0x10,
+			},
+		},
+		{
+			desc: "OK REG3",
+			kv:   "REG3     : 0xff",
+			s: &Status{
+				Register3: 0xff,
+			},
+		},
+		{
+			desc: "OK APCMODEL",
+			kv:   "APCMODEL : IWI",
+			s: &Status{
+				APCModel: "IWI",
 			},
 		},
 		{
@@ -131,9 +204,728 @@ func TestStatus_parseKV(t *testing.T) {
 				}
 			}
 
-			if diff := cmp.Diff(tt.s, s); diff != "" {
+			// Raw key/value pairs are verified by TestStatusRaw.
+			if diff := cmp.Diff(tt.s, s, cmpopts.IgnoreFields(Status{}, "Raw")); diff != "" {
 				t.Fatalf("unexpected status (-want +got):\n%s", diff)
 			}
 		})
 	}
 }
+
+func TestStatusRaw(t *testing.T) {
+	kvs := []string{
+		"LINEV    : 120.0 Volts",
+		"FOO      : bar",
+		"BAZ:",
+		"FOO      : qux",
+	}
+
+	s := new(Status)
+	for _, kv := range kvs {
+		if err := s.parseKV(kv); err != nil {
+			t.Fatalf("failed to parse key/value pair: %v", err)
+		}
+	}
+
+	want := []KeyValue{
+		{Key: "LINEV", Value: "120.0 Volts"},
+		{Key: "FOO", Value: "bar"},
+		{Key: "BAZ", Value: ""},
+		{Key: "FOO", Value: "qux"},
+	}
+
+	if diff := cmp.Diff(want, s.Raw); diff != "" {
+		t.Fatalf("unexpected raw key/value pairs (-want +got):\n%s", diff)
+	}
+
+	if v, ok := s.Lookup("FOO"); !ok || v != "bar" {
+		t.Fatalf("unexpected FOO lookup: %q, %v", v, ok)
+	}
+
+	if v, ok := s.Lookup("NOTFOUND"); ok {
+		t.Fatalf("unexpected NOTFOUND lookup: %q", v)
+	}
+}
+
+func TestStatusHas(t *testing.T) {
+	s, err := ParseStatus(strings.NewReader("ITEMP    : 0.0 C\nLINEV    : 120.0 Volts\nFOO      : bar\n"))
+	if err != nil {
+		t.Fatalf("failed to parse status: %v", err)
+	}
+
+	tests := []struct {
+		key string
+		ok  bool
+	}{
+		{key: "ITEMP", ok: true},
+		{key: "LINEV", ok: true},
+		{key: "FOO", ok: true},
+		{key: "OUTPUTV", ok: false},
+		{key: "itemp", ok: false},
+	}
+
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.ok, s.Has(tt.key)); diff != "" {
+			t.Fatalf("unexpected Has(%q) (-want +got):\n%s", tt.key, diff)
+		}
+	}
+
+	// A reported zero value and an unreported value are both zero, but only
+	// the reported value is encoded.
+	if s.InternalTemp != 0 || s.OutputVoltage != 0 {
+		t.Fatalf("unexpected non-zero values: %v, %v", s.InternalTemp, s.OutputVoltage)
+	}
+
+	b, err := s.MarshalJSON()
+	if err != nil {
+		t.Fatalf("failed to marshal JSON: %v", err)
+	}
+
+	for _, want := range []string{`"internal_temp":0`, `"output_voltage":null`} {
+		if !strings.Contains(string(b), want) {
+			t.Fatalf("JSON does not contain %s: %s", want, b)
+		}
+	}
+}
+
+func TestStatusHasParseError(t *testing.T) {
+	p := newStatusParser(new(Status), ParseLenient)
+	for _, kv := range []string{"ITEMP    : garbage", "LINEV    : 120.0 Volts", "LINEV    : garbage"} {
+		if err := p.parse(kv); err != nil {
+			t.Fatalf("failed to parse: %v", err)
+		}
+	}
+
+	s, err := p.result()
+	if err == nil {
+		t.Fatal("expected parse errors, but none occurred")
+	}
+
+	// Values which failed to parse are not reported, even if they are present
+	// in s.Raw or an earlier value parsed successfully.
+	for _, k := range []string{"ITEMP", "LINEV"} {
+		if s.Has(k) {
+			t.Fatalf("expected Has(%q) to report false", k)
+		}
+		if _, ok := s.Lookup(k); !ok {
+			t.Fatalf("expected raw value for %q", k)
+		}
+	}
+
+	b, err := s.MarshalJSON()
+	if err != nil {
+		t.Fatalf("failed to marshal JSON: %v", err)
+	}
+
+	for _, want := range []string{`"internal_temp":null`, `"line_voltage":null`} {
+		if !strings.Contains(string(b), want) {
+			t.Fatalf("JSON does not contain %s: %s", want, b)
+		}
+	}
+}
+
+func Test_parseOptionalTime(t *testing.T) {
+	edt := time.FixedZone("", -4*60*60)
+
+	tests := []struct {
+		desc string
+		in   string
+		t    time.Time
+		err  error
+	}{
+		{
+			desc: "N/A",
+			in:   "N/A",
+		},
+		{
+			desc: "long",
+			in:   "2016-09-05 21:44:09 -0400",
+			t:    time.Date(2016, time.September, 5, 21, 44, 9, 0, edt),
+		},
+		{
+			desc: "UNIX date",
+			in:   "Mon Sep 05 21:44:09 UTC 2016",
+			t:    time.Date(2016, time.September, 5, 21, 44, 9, 0, time.UTC),
+		},
+		{
+			desc: "UNIX date space padded",
+			in:   "Mon Sep  5 21:44:09 UTC 2016",
+			t:    time.Date(2016, time.September, 5, 21, 44, 9, 0, time.UTC),
+		},
+		{
+			desc: "UNIX date EDT",
+			in:   "Mon Sep 05 21:44:09 EDT 2016",
+			t:    time.Date(2016, time.September, 5, 21, 44, 9, 0, edt),
+		},
+		{
+			desc: "UNIX date CEST",
+			in:   "Sat Sep 16 15:24:53 CEST 2006",
+			t:    time.Date(2006, time.September, 16, 15, 24, 53, 0, time.FixedZone("", 2*60*60)),
+		},
+		{
+			desc: "UNIX date unknown zone",
+			in:   "Mon Sep 05 21:44:09 XYZ 2016",
+			err:  ErrInvalidTime,
+		},
+		{
+			desc: "ANSI C",
+			in:   "Mon Sep  5 21:44:09 2016",
+			t:    time.Date(2016, time.September, 5, 21, 44, 9, 0, time.Local),
+		},
+		{
+			desc: "RFC 3339",
+			in:   "2016-09-05T21:44:09-04:00",
+			t:    time.Date(2016, time.September, 5, 21, 44, 9, 0, edt),
+		},
+		{
+			desc: "no zone",
+			in:   "2016-09-05 21:44:09",
+			t:    time.Date(2016, time.September, 5, 21, 44, 9, 0, time.Local),
+		},
+		{
+			desc: "ISO 8601 no zone",
+			in:   "2016-09-05T21:44:09",
+			t:    time.Date(2016, time.September, 5, 21, 44, 9, 0, time.Local),
+		},
+		{
+			desc: "date",
+			in:   "2016-09-06",
+			t:    time.Date(2016, time.September, 6, 0, 0, 0, 0, time.Local),
+		},
+		{
+			desc: "date slashes",
+			in:   "2016/09/06",
+			t:    time.Date(2016, time.September, 6, 0, 0, 0, 0, time.Local),
+		},
+		{
+			desc: "US date",
+			in:   "09/06/2016",
+			t:    time.Date(2016, time.September, 6, 0, 0, 0, 0, time.Local),
+		},
+		{
+			desc: "US date short year",
+			in:   "09/06/16",
+			t:    time.Date(2016, time.September, 6, 0, 0, 0, 0, time.Local),
+		},
+		{
+			desc: "empty",
+			in:   "",
+			err:  ErrInvalidTime,
+		},
+		{
+			desc: "garbage",
+			in:   "yesterday",
+			err:  ErrInvalidTime,
+		},
+		{
+			desc: "invalid date",
+			in:   "13/45/16",
+			err:  ErrInvalidTime,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := parseOptionalTime(tt.in)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.t, got); diff != "" {
+				t.Fatalf("unexpected time (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStatusDrivers(t *testing.T) {
+	var (
+		edt = time.FixedZone("EDT", -4*60*60)
+		cst = time.FixedZone("CEST", 2*60*60)
+		est = time.FixedZone("EST", -5*60*60)
+		pdt = time.FixedZone("PDT", -7*60*60)
+	)
+
+	tests := []struct {
+		desc string
+		dump string
+		s    *Status
+	}{
+		{
+			desc: "USB",
+			dump: dumpUSB,
+			s: &Status{
+				APC:                         "001,036,0875",
+				Header:                      Header{Format: 1, Records: 36, Bytes: 875},
+				Date:                        time.Date(2016, time.September, 6, 22, 13, 28, 0, edt),
+				Hostname:                    "example",
+				Version:                     "3.14.14 (31 May 2016) unknown",
+				UPSName:                     "example",
+				Cable:                       "USB Cable",
+				Driver:                      "USB UPS Driver",
+				UPSMode:                     "Stand Alone",
+				StartTime:                   time.Date(2016, time.September, 6, 22, 13, 10, 0, edt),
+				Model:                       "Back-UPS XS 1300G",
+				Status:                      "TRIM ONLINE",
+				LineVoltage:                 124.0,
+				LoadPercent:                 9.0,
+				BatteryChargePercent:        100.0,
+				TimeLeft:                    73*time.Minute + 54*time.Second,
+				MinimumBatteryChargePercent: 5,
+				MinimumTimeLeft:             3 * time.Minute,
+				Sense:                       "Medium",
+				LowTransferVoltage:          88.0,
+				HighTransferVoltage:         139.0,
+				AlarmDel:                    30 * time.Second,
+				BatteryVoltage:              27.3,
+				LastTransfer:                "Automatic or explicit self test",
+				Selftest:                    SelftestNone,
+				StatusFlags:                 StatusFlagOnline | StatusFlagPlugged | StatusFlagBatteryPresent,
+				SerialNumber:                "3B1234X12345",
+				BatteryDate:                 time.Date(2013, time.September, 21, 0, 0, 0, 0, time.Local),
+				NominalInputVoltage:         120,
+				NominalBatteryVoltage:       24.0,
+				NominalPower:                780,
+				Firmware:                    "880.R2 .D USB FW:R2",
+				EndAPC:                      time.Date(2016, time.September, 6, 22, 13, 49, 0, edt),
+			},
+		},
+		{
+			desc: "SNMP",
+			dump: dumpSNMP,
+			s: &Status{
+				APC:                         "001,052,1239",
+				Header:                      Header{Format: 1, Records: 52, Bytes: 1239},
+				Date:                        time.Date(2019, time.May, 14, 9, 12, 1, 0, cst),
+				Hostname:                    "mon01",
+				Version:                     "3.14.14 (31 May 2016) debian",
+				UPSName:                     "rack-a",
+				Cable:                       "Ethernet Link",
+				Driver:                      "SNMP UPS Driver",
+				UPSMode:                     "Stand Alone",
+				StartTime:                   time.Date(2019, time.May, 1, 8, 0, 13, 0, cst),
+				Model:                       "Smart-UPS 3000 RM XL",
+				Status:                      "ONLINE",
+				LineVoltage:                 230.4,
+				LoadPercent:                 27.0,
+				BatteryChargePercent:        100.0,
+				TimeLeft:                    38 * time.Minute,
+				MinimumBatteryChargePercent: 10,
+				MinimumTimeLeft:             5 * time.Minute,
+				MaximumLineVoltage:          232.3,
+				MinimumLineVoltage:          228.9,
+				OutputVoltage:               230.4,
+				Sense:                       "High",
+				ShutdownDelay:               90 * time.Second,
+				LowBatteryDelay:             2 * time.Minute,
+				LowTransferVoltage:          196.0,
+				HighTransferVoltage:         253.0,
+				ReturnChargePercent:         15.0,
+				InternalTemp:                31.5,
+				AlarmDel:                    5 * time.Second,
+				BatteryVoltage:              54.6,
+				LineFrequency:               50.0,
+				LastTransfer:                "Line voltage notch or spike",
+				NumberTransfers:             2,
+				XOnBattery:                  time.Date(2019, time.May, 10, 3, 14, 55, 0, cst),
+				CumulativeTimeOnBattery:     12 * time.Second,
+				XOffBattery:                 time.Date(2019, time.May, 10, 3, 15, 1, 0, cst),
+				LastSelftest:                time.Date(2019, time.May, 8, 10, 0, 0, 0, cst),
+				Selftest:                    SelftestOK,
+				SelftestInterval:            "336",
+				StatusFlags:                 StatusFlagOnline | StatusFlagPlugged | StatusFlagBatteryPresent,
+				ManufactureDate:             time.Date(2012, time.May, 17, 0, 0, 0, 0, time.Local),
+				SerialNumber:                "AS1220123456",
+				BatteryDate:                 time.Date(2018, time.April, 2, 0, 0, 0, 0, time.Local),
+				NominalOutputVoltage:        230,
+				NominalBatteryVoltage:       48.0,
+				ExternalBatteries:           1,
+				Firmware:                    "690.18.I",
+				Humidity:                    38.5,
+				AmbientTemp:                 24.0,
+				EndAPC:                      time.Date(2019, time.May, 14, 9, 12, 33, 0, cst),
+			},
+		},
+		{
+			desc: "PCNET",
+			dump: dumpPCNET,
+			s: &Status{
+				APC:                         "001,044,1022",
+				Header:                      Header{Format: 1, Records: 44, Bytes: 1022},
+				Date:                        time.Date(2021, time.January, 9, 18, 30, 0, 0, est),
+				Hostname:                    "nas",
+				Version:                     "3.14.14 (31 May 2016) freebsd",
+				UPSName:                     "closet",
+				Cable:                       "Ethernet Link",
+				Driver:                      "PCNET UPS Driver",
+				UPSMode:                     "Stand Alone",
+				StartTime:                   time.Date(2021, time.January, 2, 7, 15, 42, 0, est),
+				Model:                       "Smart-UPS 1500",
+				Status:                      "ONBATT",
+				LoadPercent:                 41.6,
+				BatteryChargePercent:        88.0,
+				TimeLeft:                    21 * time.Minute,
+				MinimumBatteryChargePercent: 5,
+				MinimumTimeLeft:             3 * time.Minute,
+				OutputVoltage:               120.0,
+				Sense:                       "High",
+				ShutdownDelay:               20 * time.Second,
+				LowTransferVoltage:          106.0,
+				HighTransferVoltage:         127.0,
+				InternalTemp:                27.0,
+				AlarmDel:                    30 * time.Second,
+				BatteryVoltage:              25.9,
+				LastTransfer:                "No line voltage?",
+				NumberTransfers:             1,
+				XOnBattery:                  time.Date(2021, time.January, 9, 18, 27, 41, 0, est),
+				TimeOnBattery:               139 * time.Second,
+				CumulativeTimeOnBattery:     139 * time.Second,
+				Selftest:                    SelftestNone,
+				SelftestInterval:            "OFF",
+				StatusFlags: StatusFlagOnBattery | StatusFlagOnBatteryMessage |
+					StatusFlagFastPoll | StatusFlagPlugged | StatusFlagBatteryPresent,
+				ManufactureDate:       time.Date(2009, time.November, 4, 0, 0, 0, 0, time.Local),
+				SerialNumber:          "AS0945212345",
+				BatteryDate:           time.Date(2019, time.June, 11, 0, 0, 0, 0, time.Local),
+				NominalOutputVoltage:  120,
+				NominalBatteryVoltage: 24.0,
+				Firmware:              "601.3.D",
+				EndAPC:                time.Date(2021, time.January, 9, 18, 30, 2, 0, est),
+			},
+		},
+		{
+			desc: "modbus",
+			dump: dumpModbus,
+			s: &Status{
+				APC:                         "001,042,0992",
+				Header:                      Header{Format: 1, Records: 42, Bytes: 992},
+				Date:                        time.Date(2023, time.March, 14, 12, 0, 0, 0, time.UTC),
+				Hostname:                    "edge",
+				Version:                     "3.14.14 (31 May 2016) debian",
+				UPSName:                     "smt1500",
+				Cable:                       "USB Cable",
+				Driver:                      "MODBUS UPS Driver",
+				UPSMode:                     "Stand Alone",
+				StartTime:                   time.Date(2023, time.March, 1, 0, 0, 5, 0, time.UTC),
+				Model:                       "Smart-UPS 1500",
+				Status:                      "ONLINE REPLACEBATT",
+				LineVoltage:                 236.1,
+				LoadPercent:                 18.2,
+				LoadApparentPercent:         20.5,
+				BatteryChargePercent:        100.0,
+				TimeLeft:                    44 * time.Minute,
+				MinimumBatteryChargePercent: 5,
+				MinimumTimeLeft:             3 * time.Minute,
+				OutputVoltage:               236.1,
+				WakeDelay:                   -1 * time.Second,
+				ShutdownDelay:               60 * time.Second,
+				LowTransferVoltage:          170.0,
+				HighTransferVoltage:         280.0,
+				InternalTemp:                29.7,
+				AlarmDel:                    30 * time.Second,
+				BatteryVoltage:              27.3,
+				LineFrequency:               50.0,
+				OutputAmps:                  1.43,
+				LastTransfer:                "High line voltage",
+				Selftest:                    SelftestBatteryFailed,
+				StatusFlags: StatusFlagOnline | StatusFlagReplaceBattery |
+					StatusFlagPlugged | StatusFlagBatteryPresent,
+				ManufactureDate:       time.Date(2017, time.August, 22, 0, 0, 0, 0, time.Local),
+				SerialNumber:          "AS1734123456",
+				NominalBatteryVoltage: 24.0,
+				NominalPower:          1000,
+				NominalApparentPower:  1500,
+				Firmware:              "UPS 09.3 / ID=18",
+				EndAPC:                time.Date(2023, time.March, 14, 12, 0, 1, 0, time.UTC),
+			},
+		},
+		{
+			desc: "network",
+			dump: dumpNet,
+			s: &Status{
+				APC:                         "001,036,0917",
+				Header:                      Header{Format: 1, Records: 36, Bytes: 917},
+				Date:                        time.Date(2022, time.July, 4, 8, 0, 0, 0, pdt),
+				Hostname:                    "web02",
+				Version:                     "3.14.14 (31 May 2016) redhat",
+				UPSName:                     "rack-b",
+				Cable:                       "Ethernet Link",
+				Driver:                      "NETWORK UPS Driver",
+				UPSMode:                     "Net Slave",
+				StartTime:                   time.Date(2022, time.July, 1, 17, 22, 10, 0, pdt),
+				MasterUpdate:                time.Date(2022, time.July, 4, 7, 59, 58, 0, pdt),
+				Master:                      "ups-master.example.com:3551",
+				Model:                       "Smart-UPS 2200",
+				Status:                      "ONLINE SLAVE",
+				LineVoltage:                 122.4,
+				LoadPercent:                 33.0,
+				BatteryChargePercent:        100.0,
+				TimeLeft:                    27 * time.Minute,
+				MinimumBatteryChargePercent: 10,
+				MinimumTimeLeft:             5 * time.Minute,
+				OutputVoltage:               122.4,
+				InternalTemp:                33.3,
+				BatteryVoltage:              54.1,
+				LineFrequency:               60.0,
+				LastTransfer:                "Low line voltage",
+				NumberTransfers:             3,
+				CumulativeTimeOnBattery:     45 * time.Second,
+				XOffBattery:                 time.Date(2022, time.July, 3, 14, 2, 13, 0, pdt),
+				Selftest:                    SelftestOK,
+				StatusFlags: StatusFlagOnline | StatusFlagSlave |
+					StatusFlagPlugged | StatusFlagBatteryPresent,
+				SerialNumber:          "JS1822012345",
+				BatteryDate:           time.Date(2020, time.February, 14, 0, 0, 0, 0, time.Local),
+				NominalOutputVoltage:  120,
+				NominalBatteryVoltage: 48.0,
+				Firmware:              "UPS 10.0 / MCU 7.0",
+				EndAPC:                time.Date(2022, time.July, 4, 8, 0, 1, 0, pdt),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			s, err := ParseStatus(strings.NewReader(tt.dump))
+			if err != nil {
+				t.Fatalf("failed to parse status: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.s, s, cmpopts.IgnoreFields(Status{}, "Raw")); diff != "" {
+				t.Fatalf("unexpected status (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+const (
+	// dumpUSB is apcupsd status output from a UPS using the USB driver.
+	dumpUSB = `APC      : 001,036,0875
+DATE     : 2016-09-06 22:13:28 -0400  
+HOSTNAME : example
+VERSION  : 3.14.14 (31 May 2016) unknown
+UPSNAME  : example
+CABLE    : USB Cable
+DRIVER   : USB UPS Driver
+UPSMODE  : Stand Alone
+STARTTIME: 2016-09-06 22:13:10 -0400  
+MODEL    : Back-UPS XS 1300G 
+STATUS   : TRIM ONLINE 
+LINEV    : 124.0 Volts
+LOADPCT  : 9.0 Percent
+BCHARGE  : 100.0 Percent
+TIMELEFT : 73.9 Minutes
+MBATTCHG : 5 Percent
+MINTIMEL : 3 Minutes
+MAXTIME  : 0 Seconds
+SENSE    : Medium
+LOTRANS  : 88.0 Volts
+HITRANS  : 139.0 Volts
+ALARMDEL : 30 Seconds
+BATTV    : 27.3 Volts
+LASTXFER : Automatic or explicit self test
+NUMXFERS : 0
+TONBATT  : 0 Seconds
+CUMONBATT: 0 Seconds
+XOFFBATT : N/A
+SELFTEST : NO
+STATFLAG : 0x05000008
+SERIALNO : 3B1234X12345
+BATTDATE : 2013-09-21
+NOMINV   : 120 Volts
+NOMBATTV : 24.0 Volts
+NOMPOWER : 780 Watts
+FIRMWARE : 880.R2 .D USB FW:R2
+END APC  : 2016-09-06 22:13:49 -0400  
+`
+
+	// dumpSNMP is apcupsd status output from a UPS using the SNMP driver.
+	dumpSNMP = `APC      : 001,052,1239
+DATE     : 2019-05-14 09:12:01 +0200  
+HOSTNAME : mon01
+VERSION  : 3.14.14 (31 May 2016) debian
+UPSNAME  : rack-a
+CABLE    : Ethernet Link
+DRIVER   : SNMP UPS Driver
+UPSMODE  : Stand Alone
+STARTTIME: 2019-05-01 08:00:13 +0200  
+MODEL    : Smart-UPS 3000 RM XL
+STATUS   : ONLINE 
+LINEV    : 230.4 Volts
+LOADPCT  : 27.0 Percent
+BCHARGE  : 100.0 Percent
+TIMELEFT : 38.0 Minutes
+MBATTCHG : 10 Percent
+MINTIMEL : 5 Minutes
+MAXTIME  : 0 Seconds
+MAXLINEV : 232.3 Volts
+MINLINEV : 228.9 Volts
+OUTPUTV  : 230.4 Volts
+SENSE    : High
+DWAKE    : 0 Seconds
+DSHUTD   : 90 Seconds
+DLOWBATT : 2 Minutes
+LOTRANS  : 196.0 Volts
+HITRANS  : 253.0 Volts
+RETPCT   : 15.0 Percent
+ITEMP    : 31.5 C
+ALARMDEL : 5 Seconds
+BATTV    : 54.6 Volts
+LINEFREQ : 50.0 Hz
+LASTXFER : Line voltage notch or spike
+NUMXFERS : 2
+XONBATT  : 2019-05-10 03:14:55 +0200  
+TONBATT  : 0 Seconds
+CUMONBATT: 12 Seconds
+XOFFBATT : 2019-05-10 03:15:01 +0200  
+LASTSTEST: 2019-05-08 10:00:00 +0200  
+SELFTEST : OK
+STESTI   : 336
+STATFLAG : 0x05000008
+MANDATE  : 2012-05-17
+SERIALNO : AS1220123456
+BATTDATE : 2018-04-02
+NOMOUTV  : 230 Volts
+NOMBATTV : 48.0 Volts
+EXTBATTS : 1
+BADBATTS : 0
+FIRMWARE : 690.18.I
+HUMIDITY : 38.5 Percent
+AMBTEMP  : 24.0 C
+END APC  : 2019-05-14 09:12:33 +0200  
+`
+
+	// dumpPCNET is apcupsd status output from a UPS using the PCNET driver.
+	dumpPCNET = `APC      : 001,044,1022
+DATE     : 2021-01-09 18:30:00 -0500  
+HOSTNAME : nas
+VERSION  : 3.14.14 (31 May 2016) freebsd
+UPSNAME  : closet
+CABLE    : Ethernet Link
+DRIVER   : PCNET UPS Driver
+UPSMODE  : Stand Alone
+STARTTIME: 2021-01-02 07:15:42 -0500  
+MODEL    : Smart-UPS 1500
+STATUS   : ONBATT 
+LINEV    : 0.0 Volts
+LOADPCT  : 41.6 Percent
+BCHARGE  : 88.0 Percent
+TIMELEFT : 21.0 Minutes
+MBATTCHG : 5 Percent
+MINTIMEL : 3 Minutes
+MAXTIME  : 0 Seconds
+OUTPUTV  : 120.0 Volts
+SENSE    : High
+DWAKE    : 0 Seconds
+DSHUTD   : 20 Seconds
+LOTRANS  : 106.0 Volts
+HITRANS  : 127.0 Volts
+RETPCT   : 0.0 Percent
+ITEMP    : 27.0 C
+ALARMDEL : 30 Seconds
+BATTV    : 25.9 Volts
+LINEFREQ : 0.0 Hz
+LASTXFER : No line voltage? 
+NUMXFERS : 1
+XONBATT  : 2021-01-09 18:27:41 -0500  
+TONBATT  : 139 Seconds
+CUMONBATT: 139 Seconds
+XOFFBATT : N/A
+SELFTEST : NO
+STESTI   : OFF
+STATFLAG : 0x05060010
+MANDATE  : 2009-11-04
+SERIALNO : AS0945212345
+BATTDATE : 2019-06-11
+NOMOUTV  : 120 Volts
+NOMBATTV : 24.0 Volts
+FIRMWARE : 601.3.D
+END APC  : 2021-01-09 18:30:02 -0500  
+`
+
+	// dumpModbus is apcupsd status output from a UPS using the Modbus driver.
+	dumpModbus = `APC      : 001,042,0992
+DATE     : 2023-03-14 12:00:00 +0000  
+HOSTNAME : edge
+VERSION  : 3.14.14 (31 May 2016) debian
+UPSNAME  : smt1500
+CABLE    : USB Cable
+DRIVER   : MODBUS UPS Driver
+UPSMODE  : Stand Alone
+STARTTIME: 2023-03-01 00:00:05 +0000  
+MODEL    : Smart-UPS 1500
+STATUS   : ONLINE REPLACEBATT 
+LINEV    : 236.1 Volts
+LOADPCT  : 18.2 Percent
+LOADAPNT : 20.5 Percent
+BCHARGE  : 100.0 Percent
+TIMELEFT : 44.0 Minutes
+MBATTCHG : 5 Percent
+MINTIMEL : 3 Minutes
+MAXTIME  : 0 Seconds
+OUTPUTV  : 236.1 Volts
+DWAKE    : -1 Seconds
+DSHUTD   : 60 Seconds
+LOTRANS  : 170.0 Volts
+HITRANS  : 280.0 Volts
+ITEMP    : 29.7 C
+ALARMDEL : 30 Seconds
+BATTV    : 27.3 Volts
+LINEFREQ : 50.0 Hz
+OUTCURNT : 1.43 Amps
+LASTXFER : High line voltage
+NUMXFERS : 0
+TONBATT  : 0 Seconds
+CUMONBATT: 0 Seconds
+XOFFBATT : N/A
+SELFTEST : BT
+STATFLAG : 0x05000088
+MANDATE  : 2017-08-22
+SERIALNO : AS1734123456
+NOMBATTV : 24.0 Volts
+NOMPOWER : 1000 Watts
+NOMAPNT  : 1500 VA
+FIRMWARE : UPS 09.3 / ID=18
+END APC  : 2023-03-14 12:00:01 +0000  
+`
+
+	// dumpNet is apcupsd status output from a UPS using the network driver.
+	dumpNet = `APC      : 001,036,0917
+DATE     : 2022-07-04 08:00:00 -0700  
+HOSTNAME : web02
+VERSION  : 3.14.14 (31 May 2016) redhat
+UPSNAME  : rack-b
+CABLE    : Ethernet Link
+DRIVER   : NETWORK UPS Driver
+UPSMODE  : Net Slave
+STARTTIME: 2022-07-01 17:22:10 -0700  
+MASTERUPD: 2022-07-04 07:59:58 -0700  
+MASTER   : ups-master.example.com:3551
+MODEL    : Smart-UPS 2200
+STATUS   : ONLINE SLAVE 
+LINEV    : 122.4 Volts
+LOADPCT  : 33.0 Percent
+BCHARGE  : 100.0 Percent
+TIMELEFT : 27.0 Minutes
+MBATTCHG : 10 Percent
+MINTIMEL : 5 Minutes
+MAXTIME  : 0 Seconds
+OUTPUTV  : 122.4 Volts
+ITEMP    : 33.3 C
+BATTV    : 54.1 Volts
+LINEFREQ : 60.0 Hz
+LASTXFER : Low line voltage
+NUMXFERS : 3
+TONBATT  : 0 Seconds
+CUMONBATT: 45 Seconds
+XOFFBATT : 2022-07-03 14:02:13 -0700  
+SELFTEST : OK
+STATFLAG : 0x05000408
+SERIALNO : JS1822012345
+BATTDATE : 2020-02-14
+NOMOUTV  : 120 Volts
+NOMBATTV : 48.0 Volts
+FIRMWARE : UPS 10.0 / MCU 7.0
+END APC  : 2022-07-04 08:00:01 -0700  
+`
+)
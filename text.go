@@ -0,0 +1,282 @@
+package apcupsd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var _ encoding.TextUnmarshaler = &Status{}
+
+// ParseStatus parses a Status from r, which must contain text in the
+// "KEY : value" format which apcupsd writes to its status file (typically
+// /var/log/apcupsd.status) and which apcaccess prints. Blank lines carry no
+// data, but count toward the APC header's record and byte counts. If the text
+// does not match its APC header, an error of type *IncompleteStatusError is
+// returned, along with the Status if it is complete but its header's counts
+// are inaccurate.
+func ParseStatus(r io.Reader) (*Status, error) {
+	var d statusDecoder
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		d.add(scanner.Bytes())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return d.decode(new(Status), ParseFailFast)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing text in the same
+// format as ParseStatus. Any existing contents of s are replaced.
+func (s *Status) UnmarshalText(b []byte) error {
+	ns, err := ParseStatus(bytes.NewReader(b))
+	if ns != nil {
+		*s = *ns
+	}
+
+	return err
+}
+
+var (
+	_ encoding.TextMarshaler = &Status{}
+	_ io.WriterTo            = &Status{}
+)
+
+const (
+	// statusFormat is the STATUS format revision level written in the APC
+	// header record.
+	statusFormat = 1
+
+	// timeSuffix is appended to timestamps by apcupsd.
+	timeSuffix = "  "
+)
+
+// MarshalText implements encoding.TextMarshaler, rendering s in the same
+// format as apcupsd's status output. See WriteTo for details.
+func (s *Status) MarshalText() ([]byte, error) {
+	var b bytes.Buffer
+	if _, err := s.WriteTo(&b); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// WriteTo implements io.WriterTo, writing s to w in the same "KEY : value"
+// format as apcupsd's status output.
+//
+// The output begins with an APC header record containing the record and byte
+// counts of the records which follow it, and ends with an END APC record.
+// The APC field of s is ignored and the header is computed from the output.
+//
+// Known keys are written in apcupsd's order. A field is written if it holds a
+// non-zero value or if its key is present in s.Raw, so that values which
+// were reported as zero are preserved. Unknown keys from s.Raw are written
+// verbatim before the END APC record.
+func (s *Status) WriteTo(w io.Writer) (int64, error) {
+	recs := s.records()
+
+	// The header's counts cover every record which follows it, each
+	// terminated by a newline.
+	var size int
+	for _, r := range recs {
+		size += len(r) + 1
+	}
+
+	var b strings.Builder
+	b.Grow(size + maxString)
+	b.WriteString(formatKV(keyAPC, Header{Format: statusFormat, Records: len(recs), Bytes: size}.String()))
+	b.WriteByte('\n')
+	for _, r := range recs {
+		b.WriteString(r)
+		b.WriteByte('\n')
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// records renders each record which follows the APC header record.
+func (s *Status) records() []string {
+	rw := &recordWriter{
+		s:    s,
+		seen: make(map[key]bool),
+	}
+
+	rw.time(keyDate, s.Date)
+	rw.str(keyHostname, s.Hostname)
+	rw.str(keyVersion, s.Version)
+	rw.str(keyUPSName, s.UPSName)
+	rw.str(keyCable, s.Cable)
+	rw.str(keyDriver, s.Driver)
+	rw.str(keyUPSMode, s.UPSMode)
+	rw.time(keyStartTime, s.StartTime)
+	rw.time(keyMasterUpd, s.MasterUpdate)
+	rw.str(keyMaster, s.Master)
+	rw.str(keyShare, s.Share)
+	rw.str(keyModel, s.Model)
+	rw.str(keyStatus, s.Status)
+	rw.str(keyLineFail, s.LineFail)
+	rw.str(keyBattStat, s.BatteryStatus)
+	rw.float(keyLineV, s.LineVoltage, 1, "Volts")
+	rw.float(keyLoadPct, s.LoadPercent, 1, "Percent")
+	rw.float(keyLoadAPnt, s.LoadApparentPercent, 1, "Percent")
+	rw.float(keyBCharge, s.BatteryChargePercent, 1, "Percent")
+	rw.float(keyTimeLeft, s.TimeLeft.Minutes(), 1, "Minutes")
+	rw.float(keyMBattChg, s.MinimumBatteryChargePercent, -1, "Percent")
+	rw.minutes(keyMinTimeL, s.MinimumTimeLeft)
+	rw.seconds(keyMaxTime, s.MaximumTime)
+	rw.float(keyMaxLineV, s.MaximumLineVoltage, 1, "Volts")
+	rw.float(keyMinLineV, s.MinimumLineVoltage, 1, "Volts")
+	rw.float(keyOutV, s.OutputVoltage, 1, "Volts")
+	rw.float(keyOutputAmps, s.OutputAmps, 2, "Amps")
+	rw.str(keySense, s.Sense)
+	rw.seconds(keyDWake, s.WakeDelay)
+	rw.seconds(keyDShutd, s.ShutdownDelay)
+	rw.minutes(keyDLowBatt, s.LowBatteryDelay)
+	rw.float(keyLoTrans, s.LowTransferVoltage, 1, "Volts")
+	rw.float(keyHiTrans, s.HighTransferVoltage, 1, "Volts")
+	rw.float(keyRetPct, s.ReturnChargePercent, 1, "Percent")
+	rw.float(keyITemp, s.InternalTemp, 1, "C")
+
+	// ALARMDEL may be reported as text such as "No alarm", which is parsed
+	// as zero, so prefer the original text in that case.
+	if v, ok := s.Lookup(string(keyAlarmDel)); ok && s.AlarmDel == 0 {
+		rw.str(keyAlarmDel, v)
+	} else {
+		rw.seconds(keyAlarmDel, s.AlarmDel)
+	}
+
+	rw.float(keyBattV, s.BatteryVoltage, 1, "Volts")
+	rw.float(keyLineFrequency, s.LineFrequency, 1, "Hz")
+	rw.str(keyLastXfer, s.LastTransfer)
+	rw.int(keyNumXfers, s.NumberTransfers, "")
+	rw.time(keyXOnBat, s.XOnBattery)
+	rw.seconds(keyTOnBatt, s.TimeOnBattery)
+	rw.seconds(keyCumOnBatt, s.CumulativeTimeOnBattery)
+	rw.time(keyXOffBat, s.XOffBattery)
+	rw.time(keyLastStest, s.LastSelftest)
+	rw.str(keySelftest, string(s.Selftest))
+	rw.str(keyStestI, s.SelftestInterval)
+	rw.hex(keyStatFlag, uint64(s.StatusFlags), 8)
+	rw.hex(keyDipSw, uint64(s.DipSwitch), 2)
+	rw.hex(keyReg1, uint64(s.Register1), 2)
+	rw.hex(keyReg2, uint64(s.Register2), 2)
+	rw.hex(keyReg3, uint64(s.Register3), 2)
+	rw.date(keyManDate, s.ManufactureDate)
+	rw.str(keySerialNo, s.SerialNumber)
+	rw.date(keyBattDate, s.BatteryDate)
+	rw.float(keyNomOutV, s.NominalOutputVoltage, -1, "Volts")
+	rw.float(keyNomInV, s.NominalInputVoltage, -1, "Volts")
+	rw.float(keyNomBattV, s.NominalBatteryVoltage, 1, "Volts")
+	rw.int(keyNomPower, s.NominalPower, "Watts")
+	rw.int(keyNomAPnt, s.NominalApparentPower, "VA")
+	rw.float(keyHumidity, s.Humidity, 1, "Percent")
+	rw.float(keyAmbTemp, s.AmbientTemp, 1, "C")
+	rw.int(keyExtBatts, s.ExternalBatteries, "")
+	rw.int(keyBadBatts, s.BadBatteries, "")
+	rw.str(keyFirmware, s.Firmware)
+	rw.str(keyAPCModel, s.APCModel)
+
+	// Write any keys which do not correspond to a field verbatim.
+	for _, kv := range s.Raw {
+		k := key(kv.Key)
+		if k == keyAPC || k == keyEndAPC || rw.seen[k] {
+			continue
+		}
+
+		rw.recs = append(rw.recs, formatKV(k, kv.Value))
+	}
+
+	// The END APC record is always present to terminate the output.
+	rw.recs = append(rw.recs, formatKV(keyEndAPC, formatTime(s.EndAPC)))
+
+	return rw.recs
+}
+
+// A recordWriter renders Status fields as records in apcupsd's text format.
+type recordWriter struct {
+	s    *Status
+	recs []string
+	seen map[key]bool
+}
+
+// add adds a record for k with value v, unless zero is true and k was not
+// reported in the Status's raw key/value pairs.
+func (rw *recordWriter) add(k key, v string, zero bool) {
+	rw.seen[k] = true
+
+	if zero && !rw.s.Has(string(k)) {
+		return
+	}
+
+	rw.recs = append(rw.recs, formatKV(k, v))
+}
+
+func (rw *recordWriter) str(k key, v string) { rw.add(k, v, v == "") }
+
+func (rw *recordWriter) time(k key, t time.Time) { rw.add(k, formatTime(t), t.IsZero()) }
+
+func (rw *recordWriter) date(k key, t time.Time) { rw.add(k, formatDate(t), t.IsZero()) }
+
+func (rw *recordWriter) float(k key, f float64, prec int, unit string) {
+	rw.add(k, withUnit(strconv.FormatFloat(f, 'f', prec, 64), unit), f == 0)
+}
+
+func (rw *recordWriter) int(k key, i int, unit string) {
+	rw.add(k, withUnit(strconv.Itoa(i), unit), i == 0)
+}
+
+func (rw *recordWriter) hex(k key, u uint64, width int) {
+	rw.add(k, fmt.Sprintf("0x%0*X", width, u), u == 0)
+}
+
+func (rw *recordWriter) minutes(k key, d time.Duration) {
+	rw.add(k, withUnit(strconv.FormatFloat(d.Minutes(), 'f', -1, 64), "Minutes"), d == 0)
+}
+
+func (rw *recordWriter) seconds(k key, d time.Duration) {
+	rw.add(k, withUnit(strconv.FormatFloat(d.Seconds(), 'f', -1, 64), "Seconds"), d == 0)
+}
+
+// formatKV formats a key/value pair as a record in apcupsd's text format.
+func formatKV(k key, v string) string {
+	return fmt.Sprintf("%-9s: %s", k, v)
+}
+
+// formatTime formats a time.Time as apcupsd does, mapping the zero value to
+// "N/A".
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "N/A"
+	}
+
+	return t.Format(timeFormatLong) + timeSuffix
+}
+
+// formatDate formats the date of a time.Time as apcupsd does, mapping the zero
+// value to "N/A".
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return "N/A"
+	}
+
+	return t.Format(dateFormat)
+}
+
+// withUnit appends a unit to a value, if unit is not empty.
+func withUnit(v, unit string) string {
+	if unit == "" {
+		return v
+	}
+
+	return v + " " + unit
+}
@@ -0,0 +1,144 @@
+package apcupsd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestParseStatus(t *testing.T) {
+	const in = `
+HOSTNAME : example
+LINEV    : 120.0 Volts
+
+TIMELEFT : 10.5 Minutes
+FOO      : bar
+`
+
+	want := &Status{
+		Hostname:    "example",
+		LineVoltage: 120.0,
+		TimeLeft:    10*time.Minute + 30*time.Second,
+		Raw: []KeyValue{
+			{Key: "HOSTNAME", Value: "example"},
+			{Key: "LINEV", Value: "120.0 Volts"},
+			{Key: "TIMELEFT", Value: "10.5 Minutes"},
+			{Key: "FOO", Value: "bar"},
+		},
+	}
+
+	got, err := ParseStatus(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("failed to parse status: %v", err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected Status (-want +got):\n%s", diff)
+	}
+
+	// UnmarshalText must produce the same result and replace any existing
+	// contents.
+	s := &Status{Model: "stale"}
+	if err := s.UnmarshalText([]byte(in)); err != nil {
+		t.Fatalf("failed to unmarshal status: %v", err)
+	}
+
+	if diff := cmp.Diff(want, s); diff != "" {
+		t.Fatalf("unexpected unmarshaled Status (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseStatusError(t *testing.T) {
+	_, err := ParseStatus(strings.NewReader("HOSTNAME : example\nbad line\n"))
+	if !errors.Is(err, ErrInvalidKeyValuePair) {
+		t.Fatalf("expected invalid key/value pair, but got: %v", err)
+	}
+
+	var s Status
+	if err := s.UnmarshalText([]byte("TIMELEFT : 1")); !errors.Is(err, ErrInvalidDuration) {
+		t.Fatalf("expected invalid duration, but got: %v", err)
+	}
+}
+
+func TestStatusMarshalText(t *testing.T) {
+	s := &Status{
+		APC:             "ignored",
+		Date:            time.Date(2020, time.April, 27, 10, 0, 0, 0, time.UTC),
+		Hostname:        "example",
+		Status:          "ONLINE",
+		LineVoltage:     120,
+		TimeLeft:        10*time.Minute + 30*time.Second,
+		MinimumTimeLeft: 3 * time.Minute,
+		NumberTransfers: 0,
+		Selftest:        SelftestOK,
+		StatusFlags:     StatusFlagOnline | StatusFlagPlugged | StatusFlagBatteryPresent,
+		NominalPower:    865,
+		OutputAmps:      1.5,
+		Raw: []KeyValue{
+			// Reported as zero, so must be written.
+			{Key: "NUMXFERS", Value: "0"},
+			{Key: "ALARMDEL", Value: "No alarm"},
+			{Key: "FOO", Value: "bar"},
+		},
+	}
+
+	const want = `APC      : 001,014,0285
+DATE     : 2020-04-27 10:00:00 +0000  
+HOSTNAME : example
+STATUS   : ONLINE
+LINEV    : 120.0 Volts
+TIMELEFT : 10.5 Minutes
+MINTIMEL : 3 Minutes
+OUTCURNT : 1.50 Amps
+ALARMDEL : No alarm
+NUMXFERS : 0
+SELFTEST : OK
+STATFLAG : 0x05000008
+NOMPOWER : 865 Watts
+FOO      : bar
+END APC  : N/A
+`
+
+	b, err := s.MarshalText()
+	if err != nil {
+		t.Fatalf("failed to marshal status: %v", err)
+	}
+
+	if diff := cmp.Diff(want, string(b)); diff != "" {
+		t.Fatalf("unexpected text (-want +got):\n%s", diff)
+	}
+}
+
+func TestStatusMarshalTextRoundTrip(t *testing.T) {
+	for _, dump := range []string{dumpUSB, dumpSNMP, dumpPCNET, dumpModbus, dumpNet} {
+		want, err := ParseStatus(strings.NewReader(dump))
+		if err != nil {
+			t.Fatalf("failed to parse status: %v", err)
+		}
+
+		var b strings.Builder
+		if _, err := want.WriteTo(&b); err != nil {
+			t.Fatalf("failed to write status: %v", err)
+		}
+
+		got, err := ParseStatus(strings.NewReader(b.String()))
+		if err != nil {
+			t.Fatalf("failed to parse written status: %v", err)
+		}
+
+		// The header is recomputed and keys are written in apcupsd's order.
+		opts := []cmp.Option{
+			cmpopts.IgnoreFields(Status{}, "APC", "Header"),
+			cmpopts.IgnoreSliceElements(func(kv KeyValue) bool { return kv.Key == "APC" }),
+			cmpopts.SortSlices(func(x, y KeyValue) bool { return x.Key < y.Key }),
+		}
+
+		if diff := cmp.Diff(want, got, opts...); diff != "" {
+			t.Fatalf("unexpected round trip Status (-want +got):\n%s", diff)
+		}
+	}
+}
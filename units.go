@@ -0,0 +1,237 @@
+package apcupsd
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Unit is a unit of measurement reported by a NIS alongside a numeric
+// value, such as "120.0 Volts".
+type Unit string
+
+// Possible Unit values.
+const (
+	UnitNone       Unit = ""
+	UnitVolts      Unit = "Volts"
+	UnitAmps       Unit = "Amps"
+	UnitWatts      Unit = "Watts"
+	UnitVoltAmps   Unit = "VA"
+	UnitHertz      Unit = "Hz"
+	UnitPercent    Unit = "Percent"
+	UnitCelsius    Unit = "C"
+	UnitFahrenheit Unit = "F"
+	UnitHours      Unit = "Hours"
+	UnitMinutes    Unit = "Minutes"
+	UnitSeconds    Unit = "Seconds"
+)
+
+// unitNames maps the lowercase unit names reported by various apcupsd
+// versions and drivers to their Unit.
+var unitNames = map[string]Unit{
+	"volts":   UnitVolts,
+	"volt":    UnitVolts,
+	"amps":    UnitAmps,
+	"amp":     UnitAmps,
+	"watts":   UnitWatts,
+	"watt":    UnitWatts,
+	"va":      UnitVoltAmps,
+	"hz":      UnitHertz,
+	"percent": UnitPercent,
+	"c":       UnitCelsius,
+	"f":       UnitFahrenheit,
+	"hours":   UnitHours,
+	"hour":    UnitHours,
+	"hrs":     UnitHours,
+	"hr":      UnitHours,
+	"minutes": UnitMinutes,
+	"minute":  UnitMinutes,
+	"mins":    UnitMinutes,
+	"min":     UnitMinutes,
+	"seconds": UnitSeconds,
+	"second":  UnitSeconds,
+	"secs":    UnitSeconds,
+	"sec":     UnitSeconds,
+}
+
+// durationUnits are the units which may be reported for a duration.
+var durationUnits = []Unit{UnitHours, UnitMinutes, UnitSeconds}
+
+// keyUnits is the list of units which may be reported for each numeric key.
+var keyUnits = map[key][]Unit{
+	keyAmbTemp:       {UnitCelsius, UnitFahrenheit},
+	keyBattV:         {UnitVolts},
+	keyBCharge:       {UnitPercent},
+	keyCumOnBatt:     durationUnits,
+	keyDLowBatt:      durationUnits,
+	keyDShutd:        durationUnits,
+	keyDWake:         durationUnits,
+	keyHiTrans:       {UnitVolts},
+	keyHumidity:      {UnitPercent},
+	keyITemp:         {UnitCelsius, UnitFahrenheit},
+	keyLineFrequency: {UnitHertz},
+	keyLineV:         {UnitVolts},
+	keyLoadAPnt:      {UnitPercent},
+	keyLoadPct:       {UnitPercent},
+	keyLoTrans:       {UnitVolts},
+	keyMaxLineV:      {UnitVolts},
+	keyMaxTime:       durationUnits,
+	keyMBattChg:      {UnitPercent},
+	keyMinLineV:      {UnitVolts},
+	keyMinTimeL:      durationUnits,
+	keyNomAPnt:       {UnitVoltAmps},
+	keyNomBattV:      {UnitVolts},
+	keyNomInV:        {UnitVolts},
+	keyNomOutV:       {UnitVolts},
+	keyNomPower:      {UnitWatts},
+	keyOutputAmps:    {UnitAmps},
+	keyOutV:          {UnitVolts},
+	keyRetPct:        {UnitPercent},
+	keyTimeLeft:      durationUnits,
+	keyTOnBatt:       durationUnits,
+	keyAlarmDel:      durationUnits,
+}
+
+// Unit returns the Unit reported with the numeric value for key k, such as
+// UnitVolts for "LINEV". UnitNone is returned if k was not reported, has no
+// unit, or is not a numeric value.
+//
+// Temperatures reported in Fahrenheit are converted to Celsius when parsed,
+// but Unit still returns UnitFahrenheit for them.
+func (s *Status) Unit(k string) Unit {
+	v, ok := s.Lookup(k)
+	if !ok {
+		return UnitNone
+	}
+
+	_, u, err := parseUnit(v)
+	if err != nil || checkUnit(key(k), u) != nil {
+		return UnitNone
+	}
+
+	return u
+}
+
+// parseUnit splits a value such as "120.0 Volts" into its numeric portion and
+// Unit. A value with no unit returns UnitNone. Only the first word following
+// the number names the unit, so any description which follows it, such as in
+// "13.0 Percent Load Capacity" or "29.2 C Internal", is ignored.
+func parseUnit(v string) (string, Unit, error) {
+	num, rest, _ := strings.Cut(v, " ")
+
+	name, _, _ := strings.Cut(strings.TrimSpace(rest), " ")
+	if name == "" {
+		return num, UnitNone, nil
+	}
+
+	u, ok := lookupUnit(name)
+	if !ok {
+		return "", UnitNone, ErrInvalidUnit
+	}
+
+	return num, u, nil
+}
+
+// lookupUnit looks up a unit name in unitNames, ignoring ASCII case, without
+// allocating.
+func lookupUnit(name string) (Unit, bool) {
+	// Long enough for any name in unitNames.
+	var buf [32]byte
+	if len(name) > len(buf) {
+		return UnitNone, false
+	}
+
+	b := buf[:len(name)]
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+
+		b[i] = c
+	}
+
+	u, ok := unitNames[string(b)]
+	return u, ok
+}
+
+// checkUnit verifies that u is a valid unit for k. UnitNone is always valid,
+// since some drivers omit units.
+func checkUnit(k key, u Unit) error {
+	if u == UnitNone {
+		return nil
+	}
+
+	for _, ku := range keyUnits[k] {
+		if u == ku {
+			return nil
+		}
+	}
+
+	return ErrInvalidUnit
+}
+
+// parseFloat parses a numeric value with an optional unit for k, converting
+// temperatures to Celsius.
+func parseFloat(k key, v string) (float64, error) {
+	num, u, err := parseUnit(v)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkUnit(k, u); err != nil {
+		return 0, err
+	}
+
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if u == UnitFahrenheit {
+		f = (f - 32) * 5 / 9
+	}
+
+	return f, nil
+}
+
+// parseInt parses an integer value with an optional unit for k.
+func parseInt(k key, v string) (int, error) {
+	num, u, err := parseUnit(v)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkUnit(k, u); err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(num)
+}
+
+// parseDuration parses a duration value returned from a NIS, such as
+// "10 Seconds" or "1.5 hours", as a time.Duration.
+func parseDuration(v string) (time.Duration, error) {
+	num, u, err := parseUnit(v)
+	if err != nil {
+		return 0, ErrInvalidDuration
+	}
+
+	var unit time.Duration
+	switch u {
+	case UnitHours:
+		unit = time.Hour
+	case UnitMinutes:
+		unit = time.Minute
+	case UnitSeconds:
+		unit = time.Second
+	default:
+		return 0, ErrInvalidDuration
+	}
+
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(math.Round(f * float64(unit))), nil
+}
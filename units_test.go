@@ -0,0 +1,155 @@
+package apcupsd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStatusUnits(t *testing.T) {
+	tests := []struct {
+		name string
+		kv   string
+		s    *Status
+		unit Unit
+		err  error
+	}{
+		{
+			name: "volts",
+			kv:   "LINEV    : 120.0 Volts",
+			s:    &Status{LineVoltage: 120},
+			unit: UnitVolts,
+		},
+		{
+			name: "no unit",
+			kv:   "LINEV    : 120.0",
+			s:    &Status{LineVoltage: 120},
+			unit: UnitNone,
+		},
+		{
+			name: "lowercase",
+			kv:   "LINEFREQ : 60.0 hz",
+			s:    &Status{LineFrequency: 60},
+			unit: UnitHertz,
+		},
+		{
+			name: "percent load capacity",
+			kv:   "LOADPCT  : 13.0 Percent Load Capacity",
+			s:    &Status{LoadPercent: 13},
+			unit: UnitPercent,
+		},
+		{
+			name: "celsius",
+			kv:   "ITEMP    : 29.5 C",
+			s:    &Status{InternalTemp: 29.5},
+			unit: UnitCelsius,
+		},
+		{
+			name: "celsius with description",
+			kv:   "ITEMP    : 29.2 C Internal",
+			s:    &Status{InternalTemp: 29.2},
+			unit: UnitCelsius,
+		},
+		{
+			name: "fahrenheit",
+			kv:   "AMBTEMP  : 77.0 F",
+			s:    &Status{AmbientTemp: 25},
+			unit: UnitFahrenheit,
+		},
+		{
+			name: "watts",
+			kv:   "NOMPOWER : 865 Watts",
+			s:    &Status{NominalPower: 865},
+			unit: UnitWatts,
+		},
+		{
+			name: "hours",
+			kv:   "TIMELEFT : 1.5 Hours",
+			s:    &Status{TimeLeft: 90 * time.Minute},
+			unit: UnitHours,
+		},
+		{
+			name: "singular minute",
+			kv:   "MINTIMEL : 1 minute",
+			s:    &Status{MinimumTimeLeft: time.Minute},
+			unit: UnitMinutes,
+		},
+		{
+			name: "abbreviated seconds",
+			kv:   "DWAKE    : 30 sec",
+			s:    &Status{WakeDelay: 30 * time.Second},
+			unit: UnitSeconds,
+		},
+		{
+			name: "wrong unit for key",
+			kv:   "LINEV    : 120.0 Percent",
+			err:  ErrInvalidUnit,
+		},
+		{
+			name: "unknown unit",
+			kv:   "BATTV    : 13.5 Gigawatts",
+			err:  ErrInvalidUnit,
+		},
+		{
+			name: "unit on unitless key",
+			kv:   "NUMXFERS : 1 Watts",
+			err:  ErrInvalidUnit,
+		},
+		{
+			name: "non-duration unit",
+			kv:   "TIMELEFT : 10 Volts",
+			err:  ErrInvalidDuration,
+		},
+		{
+			name: "unknown duration unit",
+			kv:   "TIMELEFT : 10 fortnights",
+			err:  ErrInvalidDuration,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := new(Status)
+			err := s.parseKV(tt.kv)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected error %v, but got: %v", tt.err, err)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse key/value pair: %v", err)
+			}
+
+			k, _, _ := strings.Cut(tt.kv, ":")
+			if diff := cmp.Diff(tt.unit, s.Unit(strings.TrimSpace(k))); diff != "" {
+				t.Fatalf("unexpected Unit (-want +got):\n%s", diff)
+			}
+
+			s.Raw = nil
+			if diff := cmp.Diff(tt.s, s); diff != "" {
+				t.Fatalf("unexpected Status (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStatusUnitNotNumeric(t *testing.T) {
+	s := &Status{
+		Raw: []KeyValue{
+			{Key: "MODEL", Value: "Back-UPS XS 1300G"},
+			{Key: "ALARMDEL", Value: "No alarm"},
+			{Key: "FOO", Value: "10 Volts"},
+		},
+	}
+
+	for _, k := range []string{"MODEL", "ALARMDEL", "FOO", "LINEV"} {
+		if u := s.Unit(k); u != UnitNone {
+			t.Fatalf("unexpected Unit for %q: %q", k, u)
+		}
+	}
+}
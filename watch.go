@@ -0,0 +1,183 @@
+package apcupsd
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// A Transition is a change in UPS condition detected by comparing two
+// consecutive Status snapshots.
+type Transition int
+
+// Possible Transition values.
+const (
+	// The UPS switched to batteries.
+	TransitionOnBattery Transition = iota + 1
+	// The UPS returned to line power after running on batteries.
+	TransitionMainsReturned
+	// The UPS battery charge became low.
+	TransitionLowBattery
+	// Communications with the UPS were lost.
+	TransitionCommLost
+	// A UPS self test completed.
+	TransitionSelftestCompleted
+	// The number of transfers to batteries increased.
+	TransitionTransfer
+)
+
+// String returns the string representation of a Transition.
+func (t Transition) String() string {
+	switch t {
+	case TransitionOnBattery:
+		return "on battery"
+	case TransitionMainsReturned:
+		return "mains returned"
+	case TransitionLowBattery:
+		return "low battery"
+	case TransitionCommLost:
+		return "communications lost"
+	case TransitionSelftestCompleted:
+		return "self test completed"
+	case TransitionTransfer:
+		return "transfer"
+	default:
+		return "Transition(" + strconv.Itoa(int(t)) + ")"
+	}
+}
+
+// Transitions returns the Transitions which occurred between the prev and
+// cur snapshots of the same UPS. If prev is nil, no Transitions are
+// returned.
+func Transitions(prev, cur *Status) []Transition {
+	if prev == nil || cur == nil {
+		return nil
+	}
+
+	var ts []Transition
+	add := func(ok bool, t Transition) {
+		if ok {
+			ts = append(ts, t)
+		}
+	}
+
+	add(!onBattery(prev) && onBattery(cur), TransitionOnBattery)
+	add(onBattery(prev) && !onBattery(cur) && online(cur), TransitionMainsReturned)
+	add(!lowBattery(prev) && lowBattery(cur), TransitionLowBattery)
+	add(!commLost(prev) && commLost(cur), TransitionCommLost)
+	add(selftestCompleted(prev, cur), TransitionSelftestCompleted)
+	add(cur.NumberTransfers > prev.NumberTransfers, TransitionTransfer)
+
+	return ts
+}
+
+// An Update is a single poll result produced by Watch.
+type Update struct {
+	// The time the poll completed.
+	Time time.Time
+	// The UPS status, or nil if it could not be retrieved.
+	Status *Status
+	// The Transitions since the previous successfully retrieved Status.
+	Transitions []Transition
+	// Any error which occurred while polling the NIS.
+	Err error
+}
+
+// defaultWatchInterval is the interval used by Watch when interval is not
+// positive.
+const defaultWatchInterval = time.Minute
+
+// Watch polls the status of the NIS at the TCP address addr every interval
+// until ctx is canceled, sending an Update on the returned channel for each
+// poll. The first poll occurs immediately. The channel is closed once ctx is
+// canceled. If interval is zero or negative, a default of one minute is used.
+//
+// Failed polls produce an Update with Err set, and do not affect the
+// Transitions computed by later polls. A complete status whose APC header is
+// stale is not a failure: its Update has both Status and Err set, and its
+// Transitions are computed as usual.
+func Watch(ctx context.Context, addr string, interval time.Duration) <-chan Update {
+	rc := NewReconnectingClient("tcp", addr, nil)
+	return watch(ctx, rc.StatusContext, interval)
+}
+
+// watch implements Watch using fn to retrieve each Status.
+func watch(ctx context.Context, fn func(ctx context.Context) (*Status, error), interval time.Duration) <-chan Update {
+	if interval <= 0 {
+		// time.NewTicker panics on a non-positive interval.
+		interval = defaultWatchInterval
+	}
+
+	updC := make(chan Update, 1)
+
+	go func() {
+		defer close(updC)
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		var prev *Status
+		for {
+			s, err := fn(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+
+			u := Update{
+				Time:   time.Now(),
+				Status: s,
+				Err:    err,
+			}
+
+			if err == nil || (s != nil && isStaleHeader(err)) {
+				u.Transitions = Transitions(prev, s)
+				prev = s
+			}
+
+			select {
+			case updC <- u:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return updC
+}
+
+// onBattery reports whether s indicates the UPS is running on batteries.
+func onBattery(s *Status) bool {
+	return s.States().OnBattery() || s.StatusFlags.Has(StatusFlagOnBattery)
+}
+
+// online reports whether s indicates the UPS is running on line power.
+func online(s *Status) bool {
+	return s.States().Online() || s.StatusFlags.Has(StatusFlagOnline)
+}
+
+// lowBattery reports whether s indicates the UPS battery charge is low.
+func lowBattery(s *Status) bool {
+	return s.States().LowBattery() || s.StatusFlags.Has(StatusFlagBatteryLow)
+}
+
+// commLost reports whether s indicates communications with the UPS were lost.
+func commLost(s *Status) bool {
+	return s.States().CommLost() || s.StatusFlags.Has(StatusFlagCommLost)
+}
+
+// selftestCompleted reports whether a self test completed between prev and
+// cur.
+func selftestCompleted(prev, cur *Status) bool {
+	if cur.LastSelftest.After(prev.LastSelftest) {
+		return true
+	}
+
+	return prev.Selftest == SelftestInProgress &&
+		cur.Selftest != SelftestInProgress && cur.Selftest != ""
+}
@@ -0,0 +1,197 @@
+package apcupsd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTransitions(t *testing.T) {
+	var (
+		t0 = time.Date(2020, time.April, 27, 10, 0, 0, 0, time.UTC)
+		t1 = t0.Add(time.Hour)
+	)
+
+	tests := []struct {
+		desc      string
+		prev, cur *Status
+		ts        []Transition
+	}{
+		{
+			desc: "first snapshot",
+			cur:  &Status{Status: "ONBATT"},
+		},
+		{
+			desc: "no change",
+			prev: &Status{Status: "ONLINE", NumberTransfers: 1},
+			cur:  &Status{Status: "ONLINE", NumberTransfers: 1},
+		},
+		{
+			desc: "on battery",
+			prev: &Status{Status: "ONLINE", NumberTransfers: 1},
+			cur:  &Status{Status: "ONBATT", NumberTransfers: 2},
+			ts:   []Transition{TransitionOnBattery, TransitionTransfer},
+		},
+		{
+			desc: "on battery flags",
+			prev: &Status{StatusFlags: StatusFlagOnline},
+			cur:  &Status{StatusFlags: StatusFlagOnBattery | StatusFlagBatteryLow},
+			ts:   []Transition{TransitionOnBattery, TransitionLowBattery},
+		},
+		{
+			desc: "low battery",
+			prev: &Status{Status: "ONBATT"},
+			cur:  &Status{Status: "ONBATT LOWBATT"},
+			ts:   []Transition{TransitionLowBattery},
+		},
+		{
+			desc: "mains returned",
+			prev: &Status{Status: "ONBATT LOWBATT"},
+			cur:  &Status{Status: "ONLINE"},
+			ts:   []Transition{TransitionMainsReturned},
+		},
+		{
+			desc: "comm lost",
+			prev: &Status{Status: "ONBATT"},
+			cur:  &Status{Status: "COMMLOST"},
+			ts:   []Transition{TransitionCommLost},
+		},
+		{
+			desc: "self test time",
+			prev: &Status{LastSelftest: t0},
+			cur:  &Status{LastSelftest: t1},
+			ts:   []Transition{TransitionSelftestCompleted},
+		},
+		{
+			desc: "self test result",
+			prev: &Status{Selftest: SelftestInProgress},
+			cur:  &Status{Selftest: SelftestOK},
+			ts:   []Transition{TransitionSelftestCompleted},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if diff := cmp.Diff(tt.ts, Transitions(tt.prev, tt.cur)); diff != "" {
+				t.Fatalf("unexpected Transitions (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	p := &testProvider{s: &Status{Status: "ONLINE"}}
+	addr := testServer(t, p)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updC := Watch(ctx, addr, 10*time.Millisecond)
+
+	u := <-updC
+	if u.Err != nil {
+		t.Fatalf("failed to watch: %v", u.Err)
+	}
+	if u.Status.Status != "ONLINE" || len(u.Transitions) != 0 {
+		t.Fatalf("unexpected first update: %#v", u)
+	}
+
+	p.mu.Lock()
+	p.s = &Status{Status: "ONBATT", NumberTransfers: 1}
+	p.mu.Unlock()
+
+	// Wait for the change to be observed.
+	for u := range updC {
+		if u.Err != nil {
+			t.Fatalf("failed to watch: %v", u.Err)
+		}
+		if u.Status.Status != "ONBATT" {
+			continue
+		}
+
+		want := []Transition{TransitionOnBattery, TransitionTransfer}
+		if diff := cmp.Diff(want, u.Transitions); diff != "" {
+			t.Fatalf("unexpected Transitions (-want +got):\n%s", diff)
+		}
+
+		break
+	}
+
+	cancel()
+
+	// The channel must be closed once the context is canceled.
+	for range updC {
+	}
+}
+
+func TestWatchStaleHeader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stale := &IncompleteStatusError{
+		Header:  Header{Format: 1, Records: 2, Bytes: 50},
+		Records: 3,
+		Bytes:   79,
+		EndAPC:  true,
+	}
+
+	polls := []struct {
+		s   *Status
+		err error
+	}{
+		{s: &Status{Status: "ONLINE"}},
+		{s: &Status{Status: "ONBATT"}, err: stale},
+		// A truncated status is a failure, and is ignored.
+		{s: &Status{Status: "ONLINE"}, err: &IncompleteStatusError{Records: 1}},
+		{s: &Status{Status: "ONLINE"}},
+	}
+
+	var i int
+	updC := watch(ctx, func(_ context.Context) (*Status, error) {
+		p := polls[i%len(polls)]
+		i++
+		return p.s, p.err
+	}, time.Millisecond)
+
+	want := [][]Transition{
+		nil,
+		{TransitionOnBattery},
+		nil,
+		{TransitionMainsReturned},
+	}
+
+	for j, w := range want {
+		u := <-updC
+		if diff := cmp.Diff(polls[j].err, u.Err); diff != "" {
+			t.Fatalf("unexpected error %d (-want +got):\n%s", j, diff)
+		}
+		if diff := cmp.Diff(w, u.Transitions); diff != "" {
+			t.Fatalf("unexpected Transitions %d (-want +got):\n%s", j, diff)
+		}
+	}
+
+	cancel()
+	for range updC {
+	}
+}
+
+func TestWatchNonPositiveInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		updC := watch(ctx, func(_ context.Context) (*Status, error) {
+			return &Status{Status: "ONLINE"}, nil
+		}, interval)
+
+		// Must not panic, and the first poll occurs immediately.
+		if u := <-updC; u.Err != nil || u.Status.Status != "ONLINE" {
+			t.Fatalf("unexpected first update: %#v", u)
+		}
+
+		cancel()
+		for range updC {
+		}
+	}
+}